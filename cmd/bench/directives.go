@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/directive"
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+)
+
+// runDirectives builds this command once per directive variant of the
+// scenario callees and runs each build with the flags of this run.
+func runDirectives(modes []string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		fatal("-directives: the binary carries no build information to rebuild it from")
+	}
+	tmp, err := os.MkdirTemp("", "bench-directives")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "directives" && !strings.HasPrefix(f.Name, "test.") {
+			args = append(args, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+
+	for _, mode := range modes {
+		tag, err := directive.Tag(mode)
+		if err != nil {
+			fatal(err)
+		}
+		// A linked binary, unlike one from go run, keeps the symbol table
+		// that -traffic reads.
+		exe := filepath.Join(tmp, "bench-"+mode)
+		build := exec.Command("go", "build", "-tags="+tag, "-o", exe, info.Path)
+		build.Stdout = os.Stderr
+		build.Stderr = os.Stderr
+		if err := build.Run(); err != nil {
+			log.Printf("directives=%s: build failed, skipped: %v", mode, err)
+			exitCode = 1
+			continue
+		}
+		if !*jsonOut {
+			fmt.Printf("\ndirectives: %s\n", mode)
+		}
+		cmd := exec.Command(exe, append(slices.Clone(args), "-directive="+mode)...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				fatal(err)
+			}
+			exitCode = exitErr.ExitCode()
+		}
+	}
+}
+
+// checkDirective makes sure a build run by -directives has the variant the
+// parent asked for.
+func checkDirective() {
+	if *directiveFlag != scenario.Directive {
+		fatalf("built with the %s variant of the callees, not %s", scenario.Directive, *directiveFlag)
+	}
+}
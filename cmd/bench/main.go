@@ -0,0 +1,379 @@
+// Bench runs the registered scenarios outside of "go test".
+//
+// Usage:
+//
+//	bench [-run regexp] [-benchtime d] [-count n] [-latency] [-hist] [-json]
+//	bench -traffic [-run regexp] [-benchtime d] [-json]
+//	bench -thp modes [flags]
+//	bench -noise loads [flags]
+//	bench -directives variants [flags]
+//	bench -list
+//
+// By default every matching scenario is run as a Go benchmark and its mean
+// time per operation is printed. With -latency the operations of scenarios
+// that expose them are timed in batches instead, and the p50, p90, p99 and
+// p99.9 time per operation is printed; -hist adds a histogram per scenario.
+//
+// With -traffic the memory traffic per operation is estimated instead: the
+// bytes copied by the operation's machine code, read from the disassembly of
+// the running binary, and, where the machine exposes hardware counters, the
+// L1 data cache accesses and last level cache misses per operation.
+//
+// With -thp, a comma separated list of transparent huge page modes such as
+// "always,never", the measurement is repeated with the kernel's "enabled"
+// setting switched to each mode, which needs root, and the original setting
+// is restored afterwards, also when the run fails or is interrupted. Modes
+// the host does not allow are reported and skipped.
+//
+// With -noise the measurement runs next to a background load: memory
+// bandwidth hogs, CPU spinners and allocation churners, e.g.
+// "-noise bandwidth:2,alloc". Where the host allows it, the load runs on
+// CPUs of its own, one per worker as long as one is left over, and the
+// scenarios on the others, which the summary of the load lists. -noise
+// cannot be combined with -shards.
+//
+// With -directives, a comma separated list of "noinline", "default" and
+// "nosplit", the command is rebuilt with the callees of the scenarios marked
+// //go:noinline, left without directives or marked //go:nosplit as well,
+// and the measurement is repeated with each build; see
+// internal/scenario/callees_noinline.go. Building needs the go command and
+// the module's source.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/latency"
+	"github.com/rohanchauhan02/valuevspointer/internal/noise"
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+	"github.com/rohanchauhan02/valuevspointer/internal/shard"
+	"github.com/rohanchauhan02/valuevspointer/internal/thp"
+)
+
+var (
+	runFlag   = flag.String("run", ".", "run only the scenarios matching `regexp`")
+	list      = flag.Bool("list", false, "list the scenarios and exit")
+	benchtime = flag.String("benchtime", "1s", "run each scenario for `d`")
+	count     = flag.Int("count", 1, "run each scenario `n` times")
+	lat       = flag.Bool("latency", false, "report percentiles of the time per operation")
+	hist      = flag.Bool("hist", false, "with -latency, print a histogram per scenario")
+	traf      = flag.Bool("traffic", false, "report the memory traffic per operation")
+	thpModes  = flag.String("thp", "", "repeat the run for each transparent huge page `mode` in the comma separated list")
+	noiseFlag = flag.String("noise", "", "run the comma separated background `loads` (bandwidth, cpu, alloc, each optionally :workers) while measuring")
+	shards    = flag.Int("shards", 1, "split the scenarios between `n` processes run in parallel")
+	pin       = flag.Bool("pin", false, "with -shards, pin each process to its own CPU")
+	dirModes  = flag.String("directives", "", "repeat the run with the scenario callees built in each directive `variant` in the comma separated list")
+	jsonOut   = flag.Bool("json", false, "print the results as JSON")
+
+	// Set by the parent for the processes of -shards.
+	shardFlag = flag.String("shard", "", "run only shard `index/count` and print it as JSON")
+	cpu       = flag.Int("cpu", -1, "pin the process to CPU `n`")
+
+	// Set by the parent for the builds of -directives.
+	directiveFlag = flag.String("directive", "", "check that the callees were built in directive `variant`")
+)
+
+// exitCode is the status main exits with once every result is printed.
+var exitCode int
+
+// atExit holds the functions that undo changes this run made to the host.
+// exit runs them, so they also run when the command fails.
+var atExit []func()
+
+// exit runs the atExit functions, last added first, and exits with code.
+func exit(code int) {
+	for i := len(atExit) - 1; i >= 0; i-- {
+		atExit[i]()
+	}
+	os.Exit(code)
+}
+
+// fatal and fatalf are log.Fatal and log.Fatalf through exit.
+func fatal(v ...any) {
+	log.Print(v...)
+	exit(1)
+}
+
+func fatalf(format string, v ...any) {
+	log.Printf(format, v...)
+	exit(1)
+}
+
+// thpMode is the transparent huge page mode of the current run with -thp.
+var thpMode string
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("bench: ")
+	testing.Init()
+	flag.Parse()
+
+	scenarios, err := scenario.Match(*runFlag)
+	if err != nil {
+		fatal(err)
+	}
+	if *list {
+		for _, s := range scenarios {
+			fmt.Println(s.Name())
+		}
+		return
+	}
+	if len(scenarios) == 0 {
+		fatalf("no scenario matches %q", *runFlag)
+	}
+	if *directiveFlag != "" {
+		checkDirective()
+	} else if *dirModes != "" {
+		runDirectives(strings.Split(*dirModes, ","))
+		exit(exitCode)
+	}
+
+	if *shardFlag != "" {
+		spec, err := shard.ParseSpec(*shardFlag)
+		if err != nil {
+			fatal(err)
+		}
+		if *cpu >= 0 {
+			if err := shard.Pin(*cpu); err != nil {
+				fatal(err)
+			}
+			runtime.GOMAXPROCS(1)
+		}
+		scenarios = shard.Split(scenarios, spec)
+	}
+
+	measure := func() {
+		run(scenarios, collectBench, printBench, func(r scenario.Result) string { return r.Name })
+	}
+	if *lat || *traf {
+		d, err := time.ParseDuration(*benchtime)
+		if err != nil {
+			fatalf("-latency and -traffic need a duration for -benchtime: %v", err)
+		}
+		measure = func() {
+			run(scenarios, func(list []*scenario.Scenario) []latency.Result {
+				return collectLatency(list, d)
+			}, printLatency, func(r latency.Result) string { return r.Name })
+		}
+		if *traf {
+			measure = func() {
+				run(scenarios, func(list []*scenario.Scenario) []trafficResult {
+					return collectTraffic(list, d)
+				}, printTraffic, func(r trafficResult) string { return r.Name })
+			}
+		}
+	} else if err := scenario.SetBenchtime(*benchtime); err != nil {
+		fatal(err)
+	}
+	if *noiseFlag != "" {
+		// The load pins a process's scenarios to the CPUs it leaves over,
+		// where the shards would all run, and a pinned shard may have no
+		// CPU to spare.
+		if *shards > 1 {
+			fatal("-noise cannot be combined with -shards")
+		}
+		specs, err := noise.Parse(*noiseFlag)
+		if err != nil {
+			fatal(err)
+		}
+		quiet := measure
+		measure = func() {
+			load := noise.Start(specs)
+			if load.Shared != "" {
+				log.Printf("background load shares CPUs with the scenarios: %s", load.Shared)
+			}
+			quiet()
+			log.Printf("background load: %s", load.Stop())
+		}
+	}
+	if *thpModes != "" && *shardFlag == "" {
+		runTHP(strings.Split(*thpModes, ","), measure)
+	} else {
+		measure()
+	}
+	exit(exitCode)
+}
+
+// run measures the scenarios, in shard processes with -shards, and prints
+// the results. A shard process prints its results and environment as JSON
+// for the parent to merge.
+func run[R any](scenarios []*scenario.Scenario, collect func([]*scenario.Scenario) []R, print func([]R), name func(R) string) {
+	switch {
+	case *shardFlag != "":
+		writeJSON(shardOutput[R]{Env: shardEnv(), Results: collect(scenarios)})
+		return
+	case *shards > 1:
+		results, consistent := runShards(scenarios, name)
+		output(results, print)
+		if !consistent {
+			exitCode = 1
+		}
+		return
+	}
+	output(collect(scenarios), print)
+}
+
+func output[R any](results []R, print func([]R)) {
+	if *jsonOut {
+		writeJSON(results)
+		return
+	}
+	print(results)
+}
+
+// runTHP calls measure once per transparent huge page mode. The original
+// setting is restored after every mode, and also when measure panics or the
+// command fails or is interrupted by SIGINT or SIGTERM.
+func runTHP(modes []string, measure func()) {
+	settings, err := thp.Read()
+	if err != nil {
+		fatal(err)
+	}
+	log.Printf("transparent huge pages: enabled=%s defrag=%s", settings.Enabled.Current, settings.Defrag.Current)
+
+	var (
+		mu      sync.Mutex
+		restore func() error // set while a mode other than the original is on
+	)
+	// undo restores the original setting if it was changed and reports
+	// whether the setting is the original one.
+	undo := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if restore == nil {
+			return true
+		}
+		err := restore()
+		restore = nil
+		if err != nil {
+			log.Printf("restoring transparent huge pages to %s: %v", settings.Enabled.Current, err)
+			return false
+		}
+		return true
+	}
+	atExit = append(atExit, func() { undo() })
+	defer undo()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		s := <-sig
+		log.Printf("%v: restoring transparent huge pages to %s", s, settings.Enabled.Current)
+		exit(1)
+	}()
+
+	for _, mode := range modes {
+		mu.Lock()
+		restore, err = thp.SetEnabled(mode)
+		mu.Unlock()
+		if err != nil {
+			log.Printf("thp=%s: unsupported, skipped: %v", mode, err)
+			continue
+		}
+		thpMode = mode
+		if !*jsonOut {
+			fmt.Printf("\ntransparent huge pages: %s\n", mode)
+		}
+		measure()
+		if !undo() {
+			exitCode = 1
+		}
+	}
+}
+
+func collectBench(scenarios []*scenario.Scenario) []scenario.Result {
+	var results []scenario.Result
+	for range *count {
+		for _, s := range scenarios {
+			results = append(results, scenario.Run(s))
+		}
+	}
+	return results
+}
+
+func printBench(results []scenario.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "scenario\tn\tns/op\tB/op\tallocs/op\textra\t")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d\t%.2f\t%d\t%d\t%s\t\n", r.Name, r.N, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp, extra(r.Extra))
+	}
+	w.Flush()
+}
+
+func collectLatency(scenarios []*scenario.Scenario, d time.Duration) []latency.Result {
+	var results []latency.Result
+	for range *count {
+		for _, s := range scenarios {
+			if s.Op == nil {
+				log.Printf("%s: no single operation to time, skipped", s.Name())
+				continue
+			}
+			results = append(results, latency.Measure(s.Name(), s.Op(), d))
+		}
+	}
+	return results
+}
+
+func printLatency(results []latency.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "scenario\tbatch\tsamples\tmean\tp50\tp90\tp99\tp99.9\tmax\t")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			r.Name, r.Batch, r.Samples, r.Mean, r.P50, r.P90, r.P99, r.P999, r.Max)
+	}
+	w.Flush()
+	if *hist {
+		for _, r := range results {
+			fmt.Printf("\n%s (ns/op, %d ops per sample)\n", r.Name, r.Batch)
+			latency.WriteHistogram(os.Stdout, r)
+		}
+	}
+}
+
+func extra(m map[string]float64) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := ""
+	for _, k := range keys {
+		if s != "" {
+			s += " "
+		}
+		s += fmt.Sprintf("%.4g %s", m[k], k)
+	}
+	return s
+}
+
+func writeJSON(v any) {
+	if thpMode != "" || *directiveFlag != "" && *shardFlag == "" {
+		m := map[string]any{"results": v}
+		if thpMode != "" {
+			m["thp"] = thpMode
+		}
+		if *directiveFlag != "" {
+			m["directive"] = *directiveFlag
+		}
+		v = m
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(v); err != nil {
+		fatal(err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+	"github.com/rohanchauhan02/valuevspointer/internal/shard"
+)
+
+// shardOutput is what a shard process prints.
+type shardOutput[R any] struct {
+	Env     shard.Env `json:"env"`
+	Results []R       `json:"results"`
+}
+
+// shardEnv is the environment of this shard process.
+func shardEnv() shard.Env {
+	env := shard.Current()
+	if *cpu >= 0 {
+		env.Pinned = []int{*cpu}
+	}
+	return env
+}
+
+// parentOnly are the flags that shape the parent's run and are not passed
+// to the shard processes.
+var parentOnly = map[string]bool{"shards": true, "pin": true, "json": true, "thp": true, "list": true}
+
+// runShards runs the scenarios in *shards processes of this binary, in
+// parallel, and merges their results in scenario order. It reports whether
+// the shards ran in consistent environments, printing the differences if
+// not.
+func runShards[R any](scenarios []*scenario.Scenario, name func(R) string) ([]R, bool) {
+	var cpus []int
+	if *pin {
+		allowed, err := shard.Allowed()
+		if err != nil {
+			fatal(err)
+		}
+		if len(allowed) < *shards {
+			fatalf("pinning %d shards needs as many CPUs, %d are available", *shards, len(allowed))
+		}
+		cpus = allowed[:*shards]
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fatal(err)
+	}
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		if !parentOnly[f.Name] && !strings.HasPrefix(f.Name, "test.") {
+			args = append(args, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+
+	outputs := make([]shardOutput[R], *shards)
+	errs := make([]error, *shards)
+	var wg sync.WaitGroup
+	for i := range *shards {
+		spec := shard.Spec{Index: i, Count: *shards}
+		shardArgs := append(slices.Clone(args), "-shard="+spec.String())
+		if cpus != nil {
+			shardArgs = append(shardArgs, fmt.Sprintf("-cpu=%d", cpus[i]))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(exe, shardArgs...)
+			var stdout bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				errs[i] = fmt.Errorf("shard %s: %v", spec, err)
+				return
+			}
+			if err := json.Unmarshal(stdout.Bytes(), &outputs[i]); err != nil {
+				errs[i] = fmt.Errorf("shard %s: decoding results: %v", spec, err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	order := make(map[string]int)
+	for i, s := range scenarios {
+		order[s.Name()] = i
+	}
+	var (
+		results []R
+		envs    []shard.Env
+	)
+	for _, out := range outputs {
+		results = append(results, out.Results...)
+		envs = append(envs, out.Env)
+	}
+	slices.SortStableFunc(results, func(a, b R) int {
+		return order[name(a)] - order[name(b)]
+	})
+	diffs := shard.Compare(envs)
+	for _, d := range diffs {
+		log.Printf("inconsistent environment: %s", d)
+	}
+	return results, len(diffs) == 0
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+	"github.com/rohanchauhan02/valuevspointer/internal/traffic"
+)
+
+type trafficResult struct {
+	Name     string          `json:"name"`
+	Copies   *traffic.Copies `json:"copies,omitempty"`
+	Counters *traffic.Sample `json:"counters,omitempty"`
+}
+
+func collectTraffic(scenarios []*scenario.Scenario, d time.Duration) []trafficResult {
+	funcs, staticErr := disassemble()
+	var (
+		results    []trafficResult
+		countersOK = true
+	)
+	for _, s := range scenarios {
+		if s.Op == nil {
+			log.Printf("%s: no single operation to measure, skipped", s.Name())
+			continue
+		}
+		op := s.Op()
+		r := trafficResult{Name: s.Name()}
+		if staticErr == nil {
+			entry := runtime.FuncForPC(reflect.ValueOf(op).Pointer()).Entry()
+			c, err := traffic.Static(funcs, uint64(entry), runtime.GOARCH)
+			if err != nil {
+				staticErr = err
+			} else {
+				r.Copies = &c
+			}
+		}
+		if countersOK {
+			sample, err := traffic.Count(op, d)
+			if err != nil {
+				log.Printf("hardware counters unavailable, reporting copy sizes only: %v", err)
+				countersOK = false
+			} else {
+				r.Counters = &sample
+			}
+		}
+		results = append(results, r)
+	}
+	if staticErr != nil {
+		log.Printf("copy sizes unavailable: %v", staticErr)
+	}
+	return results
+}
+
+func printTraffic(results []trafficResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "scenario\tcopy read B/op\tcopy write B/op\tunsized copies\tl1d-loads/op\tl1d-stores/op\tllc-misses/op\tmemory B/op\t")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t", r.Name)
+		if c := r.Copies; c != nil {
+			fmt.Fprintf(w, "%d\t%d\t%d\t", c.Read, c.Write, c.Unknown)
+		} else {
+			fmt.Fprint(w, "-\t-\t-\t")
+		}
+		var sample traffic.Sample
+		if r.Counters != nil {
+			sample = *r.Counters
+		}
+		for _, ev := range []string{"l1d-loads", "l1d-stores", "llc-misses"} {
+			if v, ok := sample.Events[ev]; ok {
+				fmt.Fprintf(w, "%.1f\t", v)
+			} else {
+				fmt.Fprint(w, "-\t")
+			}
+		}
+		if b, ok := sample.MemoryBytes(); ok {
+			fmt.Fprintf(w, "%.0f\t\n", b)
+		} else {
+			fmt.Fprint(w, "-\t\n")
+		}
+	}
+	w.Flush()
+}
+
+// disassemble returns the functions of the scenario package in the running
+// binary.
+func disassemble() ([]*asm.Func, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	pkg := reflect.TypeOf(scenario.Scenario{}).PkgPath()
+	funcs, err := asm.Objdump(exe, "^"+regexp.QuoteMeta(pkg)+`\.`)
+	if err != nil && strings.Contains(err.Error(), "no symbol section") {
+		return nil, fmt.Errorf("the binary has no symbol table; build it with go build instead of go run")
+	}
+	return funcs, err
+}
@@ -0,0 +1,125 @@
+// Calibrate runs the by-value size sweep on the current machine and writes
+// the recommended threshold, with the measurements that justify it, into
+// valuevspointer.json.
+//
+// Usage:
+//
+//	calibrate [-config file] [-count n] [-benchtime d] [-n]
+//
+// With -n the recommendation is printed but the configuration is left
+// untouched.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/calibrate"
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+)
+
+var (
+	configFile = flag.String("config", "", "configuration `file` to update (default: nearest "+config.FileName+", or a new one in the current directory)")
+	count      = flag.Int("count", 5, "run the sweep `n` times")
+	benchtime  = flag.String("benchtime", "100ms", "run each scenario for `d`")
+	dryRun     = flag.Bool("n", false, "print the recommendation without writing it")
+	verbose    = flag.Bool("v", false, "print every run")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("calibrate: ")
+	testing.Init()
+	flag.Parse()
+
+	if *count < 2 {
+		log.Fatal("-count must be at least 2 to estimate a confidence interval")
+	}
+	if err := scenario.SetBenchtime(*benchtime); err != nil {
+		log.Fatal(err)
+	}
+	cfg, path, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	points, err := calibrate.Measure(*count, func(r scenario.Result) {
+		if *verbose {
+			log.Printf("%-24s %12.2f ns/op", r.Name, r.NsPerOp)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	threshold, crossover := calibrate.Recommend(points)
+
+	cal := &config.Calibration{
+		Date:      time.Now().UTC().Truncate(time.Second),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		GoVersion: runtime.Version(),
+		NumCPU:    runtime.NumCPU(),
+		Count:     *count,
+		Benchtime: *benchtime,
+		Points:    points,
+	}
+	if crossover != nil {
+		cal.Crossover = crossover.Size
+		cal.CrossoverCI = [2]float64{crossover.DiffLo, crossover.DiffHi}
+	}
+	report(cal, threshold)
+
+	if *dryRun {
+		return
+	}
+	cfg.Threshold = &threshold
+	cfg.Calibration = cal
+	if err := cfg.Save(path); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote threshold %d to %s\n", threshold, path)
+}
+
+func loadConfig() (*config.Config, string, error) {
+	if *configFile != "" {
+		cfg, err := config.Load(*configFile)
+		if os.IsNotExist(err) {
+			return config.Default(), *configFile, nil
+		}
+		return cfg, *configFile, err
+	}
+	cfg, err := config.Find(".")
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Path() == "" {
+		return cfg, filepath.Join(".", config.FileName), nil
+	}
+	return cfg, cfg.Path(), nil
+}
+
+func report(cal *config.Calibration, threshold int64) {
+	fmt.Printf("%s/%s %s, %d CPUs, %d runs of %s\n\n", cal.GOOS, cal.GOARCH, cal.GoVersion, cal.NumCPU, cal.Count, cal.Benchtime)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "size\tvalue ns/op\tpointer ns/op\tvalue-pointer 95% CI\t")
+	for _, p := range cal.Points {
+		fmt.Fprintf(w, "%d\t%.2f\t%.2f\t[%.2f, %.2f]\t\n", p.Size, p.ValueNs, p.PointerNs, p.DiffLo, p.DiffHi)
+	}
+	w.Flush()
+	fmt.Println()
+	if cal.Crossover == 0 {
+		fmt.Printf("no crossover: passing by value was never measurably slower\n")
+	} else {
+		fmt.Printf("crossover at %d bytes: by value costs [%.2f, %.2f] ns/op more (95%% CI)\n",
+			cal.Crossover, cal.CrossoverCI[0], cal.CrossoverCI[1])
+	}
+	fmt.Printf("recommended threshold: %d bytes\n", threshold)
+}
@@ -0,0 +1,119 @@
+// Claims checks the claims annotated in Markdown documents against fresh
+// measurements of the scenarios they reference, and fails when one no
+// longer holds on this toolchain and architecture. See package
+// internal/claims for the annotation syntax.
+//
+// Usage:
+//
+//	claims [-benchtime d] [-count n] [-n] [file.md ...]
+//
+// Without files it checks Readme.md. With -n the claims are parsed and
+// listed but not run.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/claims"
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+)
+
+var (
+	benchtime = flag.String("benchtime", "200ms", "run each scenario for `d`")
+	count     = flag.Int("count", 3, "run each scenario `n` times and use the median")
+	dryRun    = flag.Bool("n", false, "list the claims without checking them")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("claims: ")
+	testing.Init()
+	flag.Parse()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"Readme.md"}
+	}
+	var all []*claims.Claim
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		list, err := claims.Parse(f, file)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		all = append(all, list...)
+	}
+	if *dryRun {
+		for _, c := range all {
+			fmt.Printf("%s:%d: %s\n", c.File, c.Line, c.Source)
+		}
+		return
+	}
+	if err := scenario.SetBenchtime(*benchtime); err != nil {
+		log.Fatal(err)
+	}
+
+	results := make(map[string]scenario.Result)
+	failed := 0
+	for _, c := range all {
+		for _, name := range c.Scenarios() {
+			if _, done := results[name]; done {
+				continue
+			}
+			r, err := measure(name)
+			if err != nil {
+				log.Fatalf("%s:%d: %v", c.File, c.Line, err)
+			}
+			results[name] = r
+		}
+		v, err := claims.Check(c, results)
+		if err != nil {
+			log.Fatalf("%s:%d: %v", c.File, c.Line, err)
+		}
+		switch {
+		case v.Skipped != "":
+			fmt.Printf("%s:%d: skip  %s (only when %s)\n", c.File, c.Line, c.Source, v.Skipped)
+		case v.Holds:
+			fmt.Printf("%s:%d: ok    %s (%s)\n", c.File, c.Line, c.Source, v.Detail)
+		default:
+			failed++
+			fmt.Printf("%s:%d: FAIL  %s (%s)\n\t%s\n", c.File, c.Line, c.Source, v.Detail, c.Statement)
+		}
+	}
+	if failed > 0 {
+		log.Printf("%d of %d claims do not hold", failed, len(all))
+		os.Exit(1)
+	}
+}
+
+// measure runs the named scenario *count times and returns the run with
+// the median time per operation.
+func measure(name string) (scenario.Result, error) {
+	s := scenario.Lookup(name)
+	if s == nil {
+		return scenario.Result{}, fmt.Errorf("no scenario %s", name)
+	}
+	runs := make([]scenario.Result, max(*count, 1))
+	for i := range runs {
+		runs[i] = scenario.Run(s)
+	}
+	slices.SortFunc(runs, func(a, b scenario.Result) int {
+		switch {
+		case a.NsPerOp < b.NsPerOp:
+			return -1
+		case a.NsPerOp > b.NsPerOp:
+			return 1
+		}
+		return 0
+	})
+	return runs[len(runs)/2], nil
+}
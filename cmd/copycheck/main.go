@@ -0,0 +1,91 @@
+// Copycheck reports copies of values that exceed the size thresholds in
+// valuevspointer.json. Each check finds one cause:
+//
+//	param     receivers, parameters and results passed by value
+//	loopaddr  loop variables copied to the heap on every iteration because
+//	          their address escapes
+//	conv      conversions between strings, byte slices and arrays that copy
+//	anyarg    values boxed for variadic ...any parameters, as in fmt and log
+//
+// Usage:
+//
+//	copycheck [-config file] [-checks list] [-json] [dir ...]
+//
+// Each argument is a package directory; the current directory is used when
+// none is given. The exit status is 1 when anything is reported.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/check"
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+var (
+	configFile = flag.String("config", "", "configuration `file` (default: nearest "+config.FileName+")")
+	checkList  = flag.String("checks", "", "comma separated `list` of checks to run (default: all)")
+	jsonOut    = flag.Bool("json", false, "print findings as JSON")
+	listChecks = flag.Bool("list", false, "list the available checks and exit")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("copycheck: ")
+	flag.Parse()
+
+	if *listChecks {
+		for _, c := range check.All {
+			fmt.Printf("%-10s %s\n", c.Name, c.Doc)
+		}
+		return
+	}
+	checks, err := check.Lookup(*checkList)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	var findings []check.Finding
+	for _, dir := range dirs {
+		cfg, err := loadConfig(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pkg, err := load.Dir(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		findings = append(findings, check.Run(pkg, cfg, checks)...)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(findings); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	}
+	if len(findings) > 0 {
+		os.Exit(1)
+	}
+}
+
+func loadConfig(dir string) (*config.Config, error) {
+	if *configFile != "" {
+		return config.Load(*configFile)
+	}
+	return config.Find(dir)
+}
@@ -0,0 +1,120 @@
+// Directives compiles a package once per directive variant of its callees
+// and shows what the directives change: the frame of every callee and of
+// its callers, the stack check prologue that calls runtime.morestack, and
+// the calls that inlining removes, taking the copy of the argument with
+// them.
+//
+// Usage:
+//
+//	directives [-modes noinline,default,nosplit] [-func regexp] [-gcflags flags] [-json] [dir]
+//
+// The callees are the functions declared with //go:noinline or
+// //go:nosplit in a default build of the package, which selects the
+// variants with the build tags directive_default and directive_nosplit; see
+// internal/scenario/callees_noinline.go. Callees whose frame is too large to
+// be nosplit are flagged, as the linker would reject the build.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/directive"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+var (
+	modesFlag = flag.String("modes", strings.Join(directive.Modes, ","), "compare the comma separated directive `variants`")
+	funcFlag  = flag.String("func", "", "show only the callees matching `regexp`")
+	gcflags   = flag.String("gcflags", "", "extra compiler `flags`")
+	jsonOut   = flag.Bool("json", false, "print the callees as JSON")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("directives: ")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	var filter *regexp.Regexp
+	if *funcFlag != "" {
+		var err error
+		if filter, err = regexp.Compile(*funcFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	modes := strings.Split(*modesFlag, ",")
+
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	marked := directive.Marked(pkg)
+	if len(marked) == 0 {
+		log.Fatalf("no function in %s is declared with //go:noinline or //go:nosplit", pkg.Path)
+	}
+	listings, err := directive.Compile(dir, modes, strings.Fields(*gcflags)...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	callees := directive.Compare(listings, pkg.SymbolPrefix(), marked, filter)
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(callees); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	goarch := os.Getenv("GOARCH")
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "function\tvariant\tframe\tnosplit\tmorestack\tcalls")
+	for _, c := range callees {
+		for _, mode := range modes {
+			calls := fmt.Sprint(c.Calls[mode])
+			if c.Inlined(mode) {
+				calls = "inlined"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, mode, columns(c.Modes[mode], mode, goarch), calls)
+		}
+		for _, cl := range c.Callers {
+			for _, mode := range modes {
+				fmt.Fprintf(w, "  %s\t%s\t%s\t\n", cl.Name, mode, columns(cl.Modes[mode], "", goarch))
+			}
+		}
+	}
+	w.Flush()
+}
+
+// columns formats the frame, nosplit and morestack columns of f, noting a
+// nosplit callee too large for the linker.
+func columns(f directive.Frame, mode, goarch string) string {
+	if !f.Compiled {
+		return "-\t-\t-"
+	}
+	nosplit := yesNo(f.Nosplit)
+	if mode == "nosplit" && !directive.NosplitLegal(f, goarch) {
+		nosplit += " (over limit)"
+	}
+	return fmt.Sprintf("%d\t%s\t%s", f.Frame, nosplit, yesNo(f.Morestack))
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
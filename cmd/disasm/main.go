@@ -0,0 +1,199 @@
+// Disasm prints the machine code of a package's functions as it was linked
+// into the package's test binary, the code a benchmark runs, where the
+// compiler's -S listing shows it before linking. Calls are printed with
+// their final targets, and on amd64 every copy is annotated with its size:
+// calls to runtime.memmove and the duff routines, to runtime.growslice and
+// the conversions that copy into new memory, REP MOVS and STOS, and vector
+// loads and stores.
+//
+// Usage:
+//
+//	disasm [-func regexp] [-follow] [-binary file] [dir]
+//
+// The test binary of the package in dir is built with "go test -c" unless
+// -binary names one already built. With -follow the functions of the
+// package that the matched ones call directly are printed too. Every
+// function ends with the bytes its copies and those of the package
+// functions it calls move per call.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/traffic"
+)
+
+var (
+	funcFlag = flag.String("func", "", "print only the functions matching `regexp`, without the package prefix")
+	follow   = flag.Bool("follow", false, "also print the functions of the package they call")
+	binFlag  = flag.String("binary", "", "disassemble the linked `file` instead of building the test binary")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("disasm: ")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	filter := regexp.MustCompile("")
+	if *funcFlag != "" {
+		var err error
+		if filter, err = regexp.Compile(*funcFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	path, err := load.ImportPath(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	prefix := path + "."
+
+	bin := *binFlag
+	if bin == "" {
+		tmp, err := os.MkdirTemp("", "disasm")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+		bin = filepath.Join(tmp, "pkg.test")
+		if err := asm.BuildTest(dir, bin); err != nil {
+			log.Fatal(err)
+		}
+	}
+	funcs, err := asm.Objdump(bin, "^"+regexp.QuoteMeta(prefix))
+	if err != nil {
+		log.Fatal(err)
+	}
+	goarch := os.Getenv("GOARCH")
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	if goarch != "amd64" {
+		log.Printf("copies are only annotated in amd64 code, not %s", goarch)
+	}
+
+	byName := make(map[string]*asm.Func)
+	var queue []*asm.Func
+	for _, f := range funcs {
+		byName[f.Name] = f
+		if filter.MatchString(strings.TrimPrefix(f.Name, prefix)) {
+			queue = append(queue, f)
+		}
+	}
+	if len(queue) == 0 {
+		log.Fatalf("no function of %s matches %q", path, *funcFlag)
+	}
+	printed := make(map[string]bool)
+	for len(queue) > 0 {
+		f := queue[0]
+		queue = queue[1:]
+		if printed[f.Name] {
+			continue
+		}
+		printed[f.Name] = true
+		dump(f, funcs, prefix, goarch)
+		if *follow {
+			for _, call := range f.Calls() {
+				if g := byName[call.Target()]; g != nil {
+					queue = append(queue, g)
+				}
+			}
+		}
+	}
+}
+
+// dump writes the code of f with its notes.
+func dump(f *asm.Func, funcs []*asm.Func, prefix, goarch string) {
+	notes := make(map[int]string)
+	if goarch == "amd64" {
+		copies, _ := traffic.Find(f)
+		for _, c := range copies {
+			notes[c.Instr] = copyNote(c)
+		}
+	}
+	for i, in := range f.Instrs {
+		if note := callNote(in); note != "" && notes[i] == "" {
+			notes[i] = note
+		}
+	}
+
+	fmt.Printf("TEXT %s %s\n", strings.TrimPrefix(f.Name, prefix), f.File)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for i, in := range f.Instrs {
+		if i+1 < len(f.Instrs) && f.Instrs[i+1].Offset == in.Offset {
+			continue // a prefix such as REP, printed with its instruction
+		}
+		text := strings.TrimSpace(in.Op + " " + strings.ReplaceAll(in.Args, prefix, ""))
+		if i > 0 && f.Instrs[i-1].Offset == in.Offset {
+			text = f.Instrs[i-1].Op + "; " + text
+		}
+		if note := notes[i]; note != "" {
+			text += "\t; " + note
+		}
+		fmt.Fprintf(w, "  %s:%d\t%#x\t%s\n", in.File, in.Line, f.Addr+uint64(in.Offset), text)
+	}
+	w.Flush()
+	if goarch == "amd64" {
+		c, err := traffic.Static(funcs, f.Addr, goarch)
+		if err == nil {
+			fmt.Printf("  copies per call, with the package functions it calls: %d B read, %d B written", c.Read, c.Write)
+			if c.Unknown > 0 {
+				fmt.Printf(", %d of unknown size", c.Unknown)
+			}
+			fmt.Println()
+		}
+	}
+	fmt.Println()
+}
+
+func copyNote(c traffic.Copy) string {
+	var note string
+	switch {
+	case c.Unknown && c.Zero:
+		note = "zeroes memory, size not constant"
+	case c.Unknown:
+		note = "copy, size not constant"
+	case c.Zero:
+		note = fmt.Sprintf("zeroes %d B", c.Write)
+	case c.Write == 0:
+		note = fmt.Sprintf("loads %d B", c.Read)
+	case c.Read == 0:
+		note = fmt.Sprintf("stores %d B", c.Write)
+	default:
+		note = fmt.Sprintf("copies %d B", c.Write)
+	}
+	if c.Times > 1 {
+		note += fmt.Sprintf(", %d times", c.Times)
+	}
+	return note
+}
+
+// callNote explains the calls into the runtime that a benchmark loop
+// commonly pays for.
+func callNote(in asm.Instr) string {
+	if in.Op != "CALL" {
+		return ""
+	}
+	target := in.Target()
+	switch {
+	case strings.HasPrefix(target, "runtime.morestack"):
+		return "stack check failed: grow the stack and start over"
+	case target == "runtime.newobject", strings.HasPrefix(target, "runtime.mallocgc"):
+		return "heap allocation"
+	case strings.HasPrefix(target, "runtime.gcWriteBarrier"):
+		return "write barrier"
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+// Fields lists, for every function taking a struct above the size
+// threshold by value or by pointer, the fields it reads and writes, and
+// ranks the fields of each such type by how many functions use them. Types
+// whose rarely used fields make up most of their size are marked as
+// candidates for splitting.
+//
+// Usage:
+//
+//	fields [-config file] [-json] [dir]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/fields"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+var (
+	configFile = flag.String("config", "", "configuration `file` (default: nearest "+config.FileName+")")
+	jsonOut    = flag.Bool("json", false, "print the report as JSON")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("fields: ")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var cfg *config.Config
+	if *configFile != "" {
+		cfg, err = config.Load(*configFile)
+	} else {
+		cfg, err = config.Find(dir)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	r := fields.Analyze(pkg, cfg)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(r); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	for _, f := range r.Funcs {
+		star := ""
+		if f.Pointer {
+			star = "*"
+		}
+		fmt.Printf("%s:%d: %s(%s %s%s): uses %d of %d bytes\n",
+			load.Rel(f.Pos.Filename), f.Pos.Line, strings.TrimPrefix(f.Func, pkg.SymbolPrefix()), f.Param, star, short(f.Type), f.UsedBytes, f.Size)
+		if len(f.Reads) > 0 {
+			fmt.Printf("\treads  %s\n", strings.Join(f.Reads, ", "))
+		}
+		if len(f.Writes) > 0 {
+			fmt.Printf("\twrites %s\n", strings.Join(f.Writes, ", "))
+		}
+		for _, u := range f.Whole {
+			fmt.Printf("\twhole  %s at %s:%d\n", u.What, load.Rel(u.Pos.Filename), u.Pos.Line)
+		}
+	}
+	for _, t := range r.Types {
+		fmt.Printf("\n%s (%d bytes): %d functions use its fields", short(t.Type), t.Size, t.Funcs)
+		if t.Whole > 0 {
+			fmt.Printf(", %d more use it whole and are not counted", t.Whole)
+		}
+		fmt.Println()
+		for _, f := range t.Field {
+			heat := "cold"
+			if f.Hot {
+				heat = "hot"
+			}
+			fmt.Printf("\t%-4s %-16s %8d bytes  %d of %d functions (%d read, %d write)\n",
+				heat, f.Name, f.Size, f.Users, t.Funcs, f.Readers, f.Writers)
+		}
+		if t.Split {
+			fmt.Printf("\tsplit: the cold fields are %d of %d bytes; move them to a separate struct behind a pointer\n", t.ColdBytes, t.Size)
+		}
+	}
+}
+
+// short drops the import path from a qualified type name.
+func short(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
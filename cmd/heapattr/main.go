@@ -0,0 +1,123 @@
+// Heapattr attributes the allocations of a heap profile to the escape
+// analysis sites of a package ("moved to heap", "escapes to heap") and
+// totals those caused by passing an address to a pointer parameter. It
+// quantifies what choosing pointers over values costs in allocations.
+//
+// Usage:
+//
+//	heapattr [-gcflags flags] [-n count] [-json] heap.pprof [dir]
+//
+// The profile is the output of "go test -memprofile" or net/http/pprof's
+// heap endpoint; it should come from a build of the same source. The
+// escape analysis is rerun on dir, the current directory by default.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/escape"
+	"github.com/rohanchauhan02/valuevspointer/internal/heapattr"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/profile"
+)
+
+var (
+	gcflags = flag.String("gcflags", "", "extra compiler `flags` the profiled binary was built with")
+	limit   = flag.Int("n", 20, "show at most `count` sites")
+	jsonOut = flag.Bool("json", false, "print the report as JSON")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("heapattr: ")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: heapattr [flags] heap.pprof [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 2 {
+		dir = flag.Arg(1)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	p, err := profile.Parse(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	diags, err := escape.Run(dir, strings.Fields(*gcflags)...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r, err := heapattr.Analyze(p, pkg, diags)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(r.Sites) > *limit {
+		r.Sites = r.Sites[:*limit]
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(r); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	printReport(r)
+}
+
+func printReport(r *heapattr.Report) {
+	pct := func(v int64) float64 {
+		if r.AllocBytes == 0 {
+			return 0
+		}
+		return 100 * float64(v) / float64(r.AllocBytes)
+	}
+	fmt.Printf("allocated %s in %d objects\n", bytes(r.AllocBytes), r.AllocObjects)
+	fmt.Printf("  at escape sites of the package:  %10s (%5.1f%%) in %d objects\n", bytes(r.SiteBytes), pct(r.SiteBytes), r.SiteObjects)
+	fmt.Printf("  because of pointer parameters:   %10s (%5.1f%%) in %d objects\n", bytes(r.PointerParamBytes), pct(r.PointerParamBytes), r.PointerParamObjects)
+	if r.UnmatchedBytes > 0 {
+		fmt.Printf("  in the package, not matched:     %10s (%5.1f%%); was the profile built from this source?\n", bytes(r.UnmatchedBytes), pct(r.UnmatchedBytes))
+	}
+	for _, s := range r.Sites {
+		if s.Bytes == 0 {
+			continue
+		}
+		fmt.Printf("\n%10s %8d objects  %-13s  %s: %s\n", bytes(s.Bytes), s.Objects, s.Cause, load.Rel(s.Pos.String()), s.Message)
+		fmt.Printf("%35s  in %s\n", "", s.Function)
+		for _, v := range s.Via {
+			fmt.Printf("%35s  via %s\n", "", v)
+		}
+	}
+}
+
+func bytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
@@ -0,0 +1,130 @@
+// Hotpath ranks the functions of a package by the CPU time a profile spends
+// copying values on their behalf (runtime.memmove and runtime.duffcopy
+// frames directly under them), and lists the by-value receivers,
+// parameters and results above the configured threshold that explain it.
+// These are the candidates for passing a pointer instead.
+//
+// Usage:
+//
+//	hotpath [-sample type] [-n count] [-json] cpu.pprof [dir]
+//
+// The profile is the output of "go test -cpuprofile" or net/http/pprof.
+// dir is the package whose source is inspected, the current directory by
+// default.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/hotpath"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/profile"
+)
+
+var (
+	configFile = flag.String("config", "", "configuration `file` (default: nearest "+config.FileName+")")
+	sample     = flag.String("sample", "", "sample `type` to rank by (default: the profile's default)")
+	limit      = flag.Int("n", 20, "show at most `count` functions")
+	jsonOut    = flag.Bool("json", false, "print the report as JSON")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("hotpath: ")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: hotpath [flags] cpu.pprof [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 2 {
+		dir = flag.Arg(1)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	p, err := profile.Parse(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	value := p.Index(*sample)
+	if value < 0 {
+		log.Fatalf("profile has no sample type %q", *sample)
+	}
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var cfg *config.Config
+	if *configFile != "" {
+		cfg, err = config.Load(*configFile)
+	} else {
+		cfg, err = config.Find(dir)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := hotpath.Analyze(p, value, pkg, cfg)
+	if len(r.Candidates) > *limit {
+		r.Candidates = r.Candidates[:*limit]
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(r); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	printReport(r)
+}
+
+func printReport(r *hotpath.Report) {
+	format := func(v int64) string {
+		if r.SampleType.Unit == "nanoseconds" {
+			return time.Duration(v).Round(time.Microsecond).String()
+		}
+		return fmt.Sprintf("%d %s", v, r.SampleType.Unit)
+	}
+	pct := 0.0
+	if r.Total > 0 {
+		pct = 100 * float64(r.Copy) / float64(r.Total)
+	}
+	fmt.Printf("%s of %s (%.1f%%) %s spent copying values\n", format(r.Copy), format(r.Total), pct, r.SampleType.Type)
+	if len(r.Candidates) == 0 {
+		fmt.Println("no copying function is declared in the package")
+		return
+	}
+	for i, c := range r.Candidates {
+		fmt.Printf("\n%2d. %s  %s (%.1f%%)\n    %s\n", i+1, c.Function, format(c.Copy), c.Percent, load.Rel(c.Pos.String()))
+		if len(c.Reasons) == 0 {
+			fmt.Println("    no by-value transfer above the threshold; the copies come from elsewhere in the body")
+		}
+		for _, rs := range c.Reasons {
+			var what string
+			switch rs.Kind {
+			case "param":
+				what = fmt.Sprintf("receives %s %s by value", rs.Name, rs.Type)
+			case "call":
+				what = fmt.Sprintf("passes %s by value to %s", rs.Type, rs.Name)
+			case "result":
+				what = fmt.Sprintf("gets %s by value from %s", rs.Type, rs.Name)
+			}
+			fmt.Printf("    %-60s %8d bytes  %s\n", what, rs.Size, load.Rel(rs.Pos.String()))
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Paramloc reports where the parameters of a package's functions live, as
+// the DWARF debug information of the linked test binary describes them:
+// the registers holding each part of a value or its offset on the stack
+// when the function is entered, its type and size, and with -lists the full
+// location list. It is a check of the register-based calling convention
+// that does not go through the compiler's listings: small structs arrive in
+// registers, one per field, and large ones on the caller's stack.
+//
+// Usage:
+//
+//	paramloc [-func regexp] [-lists] [-binary file] [-json] [dir]
+//
+// The test binary of the package in dir is built with "go test -c" unless
+// -binary names one already built. Stack offsets are relative to the
+// canonical frame address (CFA), the stack pointer of the caller before the
+// call, where the arguments it passes on the stack begin. Type arguments
+// of more than 32 characters, such as the shapes of struct types, are cut
+// short and followed by a hash of the whole in the table; -json prints the
+// full names.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/paramloc"
+)
+
+var (
+	funcFlag = flag.String("func", "", "show only the functions matching `regexp`, without the package prefix")
+	lists    = flag.Bool("lists", false, "print the location list of every parameter")
+	binFlag  = flag.String("binary", "", "read the linked `file` instead of building the test binary")
+	jsonOut  = flag.Bool("json", false, "print the functions as JSON")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("paramloc: ")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	filter := regexp.MustCompile("")
+	if *funcFlag != "" {
+		var err error
+		if filter, err = regexp.Compile(*funcFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	path, err := load.ImportPath(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	prefix := path + "."
+
+	bin := *binFlag
+	if bin == "" {
+		tmp, err := os.MkdirTemp("", "paramloc")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+		bin = filepath.Join(tmp, "pkg.test")
+		if err := asm.BuildTest(dir, bin); err != nil {
+			log.Fatal(err)
+		}
+	}
+	funcs, err := paramloc.Read(bin, func(name string) bool {
+		rest, ok := strings.CutPrefix(name, prefix)
+		return ok && filter.MatchString(rest)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(funcs) == 0 {
+		log.Fatalf("no function of %s matches %q", path, *funcFlag)
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(funcs); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "function\tparameter\ttype\tsize\tkind\tat entry")
+	for _, fn := range funcs {
+		name := shortName(strings.TrimPrefix(fn.Name, prefix))
+		for _, p := range fn.Params {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", name, p.Name, strings.ReplaceAll(p.Type, prefix, ""), p.Size, p.Entry.Kind(), p.Entry)
+			if *lists {
+				for _, r := range p.List {
+					fmt.Fprintf(w, "\t\t\t\t\t  [%#x,%#x) %s\n", r.Low, r.High, r.Loc)
+				}
+			}
+		}
+		if len(fn.Params) == 0 {
+			fmt.Fprintf(w, "%s\t\t\t\t\t\n", name)
+		}
+	}
+	w.Flush()
+}
+
+// maxShape is the longest list of type arguments printed in full.
+const maxShape = 32
+
+// shortName shortens the type arguments of a generic function's name that
+// are longer than maxShape, such as the shapes of struct types, to their
+// start and a hash of the whole, so instances stay apart without widening
+// the function column. -json and -func use the full name.
+func shortName(name string) string {
+	var b strings.Builder
+	depth, start := 0, 0
+	for i, r := range name {
+		switch r {
+		case '[':
+			if depth == 0 {
+				b.WriteString(name[start : i+1])
+				start = i + 1
+			}
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				args := name[start:i]
+				if len(args) > maxShape {
+					h := fnv.New32a()
+					h.Write([]byte(args))
+					args = fmt.Sprintf("%s~%08x", args[:maxShape-9], h.Sum32())
+				}
+				b.WriteString(args)
+				start = i
+			}
+		}
+	}
+	b.WriteString(name[start:])
+	return b.String()
+}
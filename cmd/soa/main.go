@@ -0,0 +1,58 @@
+// Soa generates struct-of-arrays counterparts of struct types: for a type T,
+// a type TSoA with one slice per field of T and methods to read, replace and
+// append whole records.
+//
+// Usage:
+//
+//	soa -type T[,U...] [-o file] [dir]
+//
+// The file is written to dir, named after the first type with a "_soa.go"
+// suffix unless -o is given. It is meant to be run by go:generate:
+//
+//	//go:generate go run github.com/rohanchauhan02/valuevspointer/cmd/soa -type particle
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/layout"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma separated list of struct type `names`")
+	output    = flag.String("o", "", "output `file` (default: dir/<type>_soa.go)")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("soa: ")
+	flag.Parse()
+	if *typeNames == "" {
+		log.Fatal("-type is required")
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(names[0])+"_soa.go")
+	}
+
+	pkg, err := layout.Load(dir, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := layout.SoA(pkg, names, "soa "+strings.Join(os.Args[1:], " "))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,159 @@
+// Split splits a struct type into its hot fields and a pointer to the rest:
+// for a type T, a type THot with the hot fields and a pointer to a TCold
+// with the others, SplitT and THot.Join to convert between them, and
+// benchmarks reading the hot fields of T and THot by value and by pointer.
+//
+// Usage:
+//
+//	split -type T -hot a,b [-bench=false] [-o file] [dir]
+//	split -type T -hot auto [-config file] [dir]
+//	split -type T -report fields.json [dir]
+//
+// The hot fields are listed with -hot, found by the field access analysis
+// of cmd/fields with -hot auto, or read from a report it printed with
+// -json. The file is written to dir, named after the type with a
+// "_split.go" suffix unless -o is given, and the benchmarks next to it with
+// a "_test.go" suffix.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/fields"
+	"github.com/rohanchauhan02/valuevspointer/internal/layout"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+var (
+	typeName   = flag.String("type", "", "struct type `name`")
+	hotFlag    = flag.String("hot", "", "comma separated hot field `names`, or auto to analyze the package")
+	report     = flag.String("report", "", "take the hot fields from a `file` printed by fields -json")
+	configFile = flag.String("config", "", "with -hot auto, configuration `file` (default: nearest "+config.FileName+")")
+	bench      = flag.Bool("bench", true, "also write benchmarks against the original type")
+	output     = flag.String("o", "", "output `file` (default: dir/<type>_split.go)")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("split: ")
+	flag.Parse()
+	if *typeName == "" {
+		log.Fatal("-type is required")
+	}
+	if (*hotFlag == "") == (*report == "") {
+		log.Fatal("exactly one of -hot and -report is required")
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(*typeName)+"_split.go")
+	}
+	outputs := []string{out}
+	if *bench {
+		outputs = append(outputs, strings.TrimSuffix(out, ".go")+"_test.go")
+	}
+
+	pkg, err := layout.Load(dir, outputs...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var hot []string
+	switch {
+	case *report != "":
+		var r fields.Report
+		data, err := os.ReadFile(*report)
+		if err == nil {
+			err = json.Unmarshal(data, &r)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		hot, err = hotFields(&r, pkg)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case *hotFlag == "auto":
+		var cfg *config.Config
+		if *configFile != "" {
+			cfg, err = config.Load(*configFile)
+		} else {
+			cfg, err = config.Find(dir)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		hot, err = hotFields(fields.Analyze(pkg, cfg), pkg)
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		if hot, err = parseHot(*hotFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	command := "split " + strings.Join(os.Args[1:], " ")
+	src, err := layout.Split(pkg, *typeName, hot, command)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if *bench {
+		src, err := layout.SplitBenchmarks(pkg, *typeName, hot, command)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(outputs[1], src, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Printf("hot fields of %s: %s", *typeName, strings.Join(hot, ", "))
+}
+
+// parseHot splits the comma separated list of -hot, allowing spaces around
+// the names.
+func parseHot(list string) ([]string, error) {
+	var hot []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("-hot %q: empty field name", list)
+		case slices.Contains(hot, name):
+			return nil, fmt.Errorf("-hot %q: field %s is listed twice", list, name)
+		}
+		hot = append(hot, name)
+	}
+	return hot, nil
+}
+
+// hotFields returns the fields of -type the report found hot.
+func hotFields(r *fields.Report, pkg *load.Package) ([]string, error) {
+	name := pkg.Path + "." + *typeName
+	for _, t := range r.Types {
+		if t.Type != name {
+			continue
+		}
+		var hot []string
+		for _, f := range t.Field {
+			if f.Hot {
+				hot = append(hot, f.Name)
+			}
+		}
+		return hot, nil
+	}
+	return nil, fmt.Errorf("no function uses the fields of %s by value or pointer above the size threshold; list the hot fields with -hot", name)
+}
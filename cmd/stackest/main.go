@@ -0,0 +1,104 @@
+// Stackest estimates the worst-case stack depth reached from each entry
+// point of a package. Frame sizes come from the compiler's assembly
+// listing and the call graph from its direct CALL instructions. Chains
+// where arguments passed by value make up most of the stack are flagged,
+// with the parameters responsible.
+//
+// Usage:
+//
+//	stackest [-gcflags flags] [-n count] [-json] [dir]
+//
+// Calls into other packages and indirect calls are not followed, so the
+// estimate is a lower bound for the full program.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/stackest"
+)
+
+var (
+	configFile = flag.String("config", "", "configuration `file` (default: nearest "+config.FileName+")")
+	gcflags    = flag.String("gcflags", "", "extra compiler `flags`, e.g. \"-N -l\"")
+	limit      = flag.Int("n", 10, "show at most `count` entry points")
+	jsonOut    = flag.Bool("json", false, "print the chains as JSON")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("stackest: ")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	funcs, err := asm.Compile(dir, strings.Fields(*gcflags)...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	goarch := os.Getenv("GOARCH")
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	chains := stackest.Estimate(funcs, pkg.SymbolPrefix(), goarch)
+	var cfg *config.Config
+	if *configFile != "" {
+		cfg, err = config.Load(*configFile)
+	} else {
+		cfg, err = config.Find(dir)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	stackest.Annotate(chains, pkg, cfg)
+	if len(chains) > *limit {
+		chains = chains[:*limit]
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(chains); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	for i, c := range chains {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s: %d bytes of stack, %d (%.0f%%) in argument areas incl. register spill slots",
+			c.Entry, c.Depth, c.ArgBytes, 100*float64(c.ArgBytes)/float64(max(c.Depth, 1)))
+		if c.Dominated() {
+			fmt.Print(" [dominated by by-value arguments]")
+		}
+		if c.Recursive {
+			fmt.Print(" [recursive, cut]")
+		}
+		fmt.Println()
+		for _, s := range c.Steps {
+			fmt.Printf("  %10d  %-40s %s:%d\n", s.Frame, s.Func, load.Rel(s.File), s.Line)
+			if s.Args > 0 {
+				fmt.Printf("  %10s  argument area %d bytes in the caller's frame, incl. register spill slots\n", "", s.Args)
+			}
+			for _, p := range s.Params {
+				fmt.Printf("  %10s  by value: %s\n", "", p)
+			}
+		}
+	}
+}
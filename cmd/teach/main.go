@@ -0,0 +1,58 @@
+// Teach walks through guided experiments on passing values and pointers:
+// predict the outcome, watch the benchmark or the compiler run, and see
+// whether the prediction was right.
+//
+// Usage:
+//
+//	teach [-benchtime d] [-lesson n] [-list]
+//
+// Predictions are read from standard input, one choice letter per line, so
+// a session can also be scripted.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+	"github.com/rohanchauhan02/valuevspointer/internal/teach"
+)
+
+var (
+	benchtime = flag.String("benchtime", "200ms", "run each benchmark for `d`")
+	first     = flag.Int("lesson", 1, "start at lesson `n`")
+	list      = flag.Bool("list", false, "list the lessons and exit")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("teach: ")
+	testing.Init()
+	flag.Parse()
+
+	if *list {
+		for i, l := range teach.Lessons {
+			fmt.Printf("%d. %s\n", i+1, l.Title)
+		}
+		return
+	}
+	if *first < 1 || *first > len(teach.Lessons) {
+		log.Fatalf("-lesson must be between 1 and %d", len(teach.Lessons))
+	}
+	if err := scenario.SetBenchtime(*benchtime); err != nil {
+		log.Fatal(err)
+	}
+	dir, err := os.MkdirTemp("", "teach")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := teach.Run(teach.Lessons[*first-1:], &teach.Env{Dir: dir}, os.Stdin, os.Stdout); err != nil {
+		os.RemoveAll(dir)
+		log.Fatal(err)
+	}
+}
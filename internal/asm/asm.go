@@ -0,0 +1,165 @@
+// Package asm runs the compiler with -S on a package, or the disassembler on
+// a linked binary, and decodes the listing: the functions, their frame and
+// argument sizes and their instructions.
+package asm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Func is a function in the listing.
+type Func struct {
+	Name   string
+	Addr   uint64   // entry address, for functions of a linked binary
+	Flags  []string // header flags such as "leaf", "nosplit", "dupok", "wrapper"
+	Size   int64    // code size in bytes
+	Frame  int64    // frame size, from the TEXT directive
+	Args   int64    // size of the arguments passed on the stack
+	File   string
+	Line   int
+	Instrs []Instr
+}
+
+// Instr is one instruction or pseudo-instruction.
+type Instr struct {
+	Offset int64  // from the start of the function
+	File   string // base name only in disassembled binaries
+	Line   int
+	Op     string
+	Args   string
+}
+
+// Has reports whether the header of f carries the flag.
+func (f *Func) Has(flag string) bool {
+	for _, fl := range f.Flags {
+		if fl == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Calls returns the direct call targets of f in order, e.g.
+// "main.PassByValue" or "runtime.morestack_noctxt".
+func (f *Func) Calls() []Instr {
+	var calls []Instr
+	for _, in := range f.Instrs {
+		if (in.Op == "CALL" || in.Op == "BL") && strings.HasSuffix(in.Args, "(SB)") {
+			calls = append(calls, in)
+		}
+	}
+	return calls
+}
+
+// Target returns the symbol called by a CALL instruction.
+func (in Instr) Target() string {
+	return strings.TrimSuffix(in.Args, "(SB)")
+}
+
+// Compile builds the package in dir with -gcflags=-S plus gcflags and
+// returns the functions of its listing.
+func Compile(dir string, gcflags ...string) ([]*Func, error) {
+	return CompileTags(dir, nil, gcflags...)
+}
+
+// CompileTags is like Compile with the build tags set.
+func CompileTags(dir string, tags []string, gcflags ...string) ([]*Func, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	flags := append([]string{"-S"}, gcflags...)
+	cmd := exec.Command("go", "build", "-tags="+strings.Join(tags, ","), "-gcflags="+strings.Join(flags, " "), "-o", os.DevNull, ".")
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go build: %v\n%s", err, out.Bytes())
+	}
+	return Parse(&out)
+}
+
+var (
+	headerRE = regexp.MustCompile(`^(\S+) STEXT(.*)$`)
+	instrRE  = regexp.MustCompile(`^\t0x([0-9a-f]+) \d+ \((.*):(\d+)\)\t(\S+)\t?(.*)$`)
+	textRE   = regexp.MustCompile(`\$(-?\d+)-(\d+)$`)
+)
+
+// Parse decodes a listing as printed by "go tool compile -S". Data symbols
+// and the hex dumps of code are skipped.
+func Parse(r io.Reader) ([]*Func, error) {
+	var (
+		funcs []*Func
+		cur   *Func
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := headerRE.FindStringSubmatch(line); m != nil {
+			cur = &Func{Name: m[1]}
+			for _, field := range strings.Fields(m[2]) {
+				k, v, ok := strings.Cut(field, "=")
+				if !ok {
+					cur.Flags = append(cur.Flags, k)
+					continue
+				}
+				switch k {
+				case "size":
+					cur.Size, _ = strconv.ParseInt(v, 0, 64)
+				case "args":
+					cur.Args, _ = strconv.ParseInt(v, 0, 64)
+				}
+			}
+			funcs = append(funcs, cur)
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			cur = nil // a data symbol or a package header
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		m := instrRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		off, _ := strconv.ParseInt(m[1], 16, 64)
+		ln, _ := strconv.Atoi(m[3])
+		in := Instr{Offset: off, File: m[2], Line: ln, Op: m[4], Args: strings.TrimSpace(m[5])}
+		if in.Op == "TEXT" {
+			cur.File, cur.Line = in.File, in.Line
+			if t := textRE.FindStringSubmatch(in.Args); t != nil {
+				cur.Frame, _ = strconv.ParseInt(t[1], 10, 64)
+				if cur.Frame < 0 {
+					cur.Frame = 0 // $-4 marks NOFRAME on some architectures
+				}
+			}
+		}
+		cur.Instrs = append(cur.Instrs, in)
+	}
+	return funcs, sc.Err()
+}
+
+// ReturnAddr is the stack a call pushes on architectures that do not keep
+// the return address in a register, and that frame sizes do not include.
+func ReturnAddr(goarch string) int64 {
+	switch goarch {
+	case "amd64":
+		return 8
+	case "386":
+		return 4
+	}
+	return 0
+}
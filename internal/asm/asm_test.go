@@ -0,0 +1,100 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+)
+
+const listing = `# example.com/p
+main.take STEXT nosplit size=13 align=0x0 args=0x10000 locals=0x0 funcid=0x0
+	0x0000 00000 (/tmp/demo/main.go:15)	TEXT	main.take(SB), NOSPLIT|NOFRAME|ABIInternal, $0-65536
+	0x0000 00000 (/tmp/demo/main.go:15)	MOVBLZX	main.v+8(SP), AX
+	0x000c 00012 (/tmp/demo/main.go:15)	RET
+	0x0000 0f b6 44 24 08 48 01 05 00 00 00 00 c3           ..D$.H.......
+	rel 8+4 t=R_PCREL main.n+0
+main.loop STEXT size=104 align=0x0 args=0x0 locals=0x10010 funcid=0x0
+	0x0000 00000 (/tmp/demo/main.go:17)	TEXT	main.loop(SB), ABIInternal, $65552-0
+	0x0039 00057 (/tmp/demo/main.go:19)	REP
+	0x003a 00058 (/tmp/demo/main.go:19)	MOVSQ
+	0x0040 00064 (/tmp/demo/main.go:19)	CALL	main.take(SB)
+	0x0060 00096 (/tmp/demo/main.go:21)	RET
+	0x0061 00097 (/tmp/demo/main.go:17)	CALL	runtime.morestack_noctxt(SB)
+main.b SBSS size=65536
+go:cuinfo.packagename.main SDWARFCUINFO dupok size=0
+	0x0000 6d 61 69 6e                                      main
+`
+
+func TestParse(t *testing.T) {
+	funcs, err := Parse(strings.NewReader(listing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funcs) != 2 {
+		t.Fatalf("got %d functions, want 2", len(funcs))
+	}
+	take, loop := funcs[0], funcs[1]
+	if take.Name != "main.take" || !take.Has("nosplit") || take.Args != 65536 || take.Frame != 0 || take.Size != 13 {
+		t.Errorf("take = %+v", take)
+	}
+	if loop.Frame != 65552 || loop.File != "/tmp/demo/main.go" || loop.Line != 17 || len(loop.Instrs) != 6 {
+		t.Errorf("loop = %+v", loop)
+	}
+	calls := loop.Calls()
+	if len(calls) != 2 || calls[0].Target() != "main.take" || calls[0].Line != 19 || calls[1].Target() != "runtime.morestack_noctxt" {
+		t.Errorf("loop calls = %+v", calls)
+	}
+	if in := loop.Instrs[1]; in.Op != "REP" || in.Args != "" {
+		t.Errorf("instruction without operands = %+v", in)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	funcs, err := Compile("../..", "-N", "-l")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range funcs {
+		if f.Name == "main.PassByValue" {
+			if f.Args != 1<<18 {
+				t.Errorf("PassByValue args = %d, want %d", f.Args, 1<<18)
+			}
+			return
+		}
+	}
+	t.Errorf("main.PassByValue not in listing")
+}
+
+const disassembly = `TEXT main.loop(SB) /tmp/demo/main.go
+  main.go:17		0x4a0000		493b6610		CMPQ SP, 0x10(R14)
+  main.go:19		0x4a0004		b900200000		MOVL $0x2000, CX
+  main.go:19		0x4a0009		f348a5			REP; MOVSQ DS:0(SI), ES:0(DI)
+  main.go:19		0x4a000c		e8ef000000		CALL main.take(SB)
+  main.go:21		0x4a0011		c3			RET
+
+TEXT main.take(SB) /tmp/demo/main.go
+  main.go:15		0x4a0100		c3			RET
+`
+
+func TestParseObjdump(t *testing.T) {
+	funcs, err := ParseObjdump(strings.NewReader(disassembly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funcs) != 2 {
+		t.Fatalf("got %d functions, want 2", len(funcs))
+	}
+	loop := funcs[0]
+	if loop.Name != "main.loop" || loop.Addr != 0x4a0000 || loop.File != "/tmp/demo/main.go" || loop.Line != 17 || loop.Size != 0x12 {
+		t.Errorf("loop = %+v", loop)
+	}
+	if len(loop.Instrs) != 6 {
+		t.Fatalf("loop has %d instructions, want 6", len(loop.Instrs))
+	}
+	rep, movs := loop.Instrs[2], loop.Instrs[3]
+	if rep.Op != "REP" || movs.Op != "MOVSQ" || movs.Args != "DS:0(SI), ES:0(DI)" || rep.Offset != 9 || movs.Offset != 9 || movs.File != "main.go" {
+		t.Errorf("REP; MOVSQ = %+v, %+v", rep, movs)
+	}
+	if calls := loop.Calls(); len(calls) != 1 || calls[0].Target() != "main.take" {
+		t.Errorf("loop calls = %+v", calls)
+	}
+}
@@ -0,0 +1,118 @@
+package asm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Objdump disassembles the functions of a linked binary whose symbol names
+// match the regular expression, with "go tool objdump -s".
+func Objdump(binary, pattern string) ([]*Func, error) {
+	cmd := exec.Command("go", "tool", "objdump", "-s", pattern, binary)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go tool objdump: %v\n%s", err, stderr.Bytes())
+	}
+	return ParseObjdump(&out)
+}
+
+// BuildTest builds the test binary of the package in dir to out with
+// "go test -c". Unlike the binaries go test and go run execute, it keeps
+// the symbol table and the debug information.
+func BuildTest(dir, out string, gcflags ...string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if out, err = filepath.Abs(out); err != nil {
+		return err
+	}
+	cmd := exec.Command("go", "test", "-c", "-o", out)
+	if len(gcflags) > 0 {
+		cmd.Args = append(cmd.Args, "-gcflags="+strings.Join(gcflags, " "))
+	}
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go test -c: %v\n%s", err, output.Bytes())
+	}
+	if _, err := os.Stat(out); err != nil {
+		return fmt.Errorf("go test -c built no binary: the package in %s has no tests", dir)
+	}
+	return nil
+}
+
+var objdumpTextRE = regexp.MustCompile(`^TEXT (.+)\(SB\)(?: (.*))?$`)
+
+// ParseObjdump decodes a listing as printed by "go tool objdump". Only the
+// names, addresses, files and instructions of the functions are known;
+// frame and argument sizes are not part of the listing. Prefixes written
+// on the same line as their instruction, like "REP; MOVSQ", become separate
+// instructions at the same offset, as in the compiler's listing.
+func ParseObjdump(r io.Reader) ([]*Func, error) {
+	var (
+		funcs []*Func
+		cur   *Func
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := objdumpTextRE.FindStringSubmatch(line); m != nil {
+			cur = &Func{Name: m[1], File: m[2]}
+			funcs = append(funcs, cur)
+			continue
+		}
+		if cur == nil || !strings.HasPrefix(line, "  ") {
+			continue
+		}
+		var fields []string
+		for _, f := range strings.Split(strings.TrimSpace(line), "\t") {
+			if f != "" {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) < 4 {
+			continue
+		}
+		i := strings.LastIndex(fields[0], ":")
+		if i < 0 {
+			continue
+		}
+		file := fields[0][:i]
+		n, _ := strconv.Atoi(fields[0][i+1:])
+		addr, err := strconv.ParseUint(strings.TrimPrefix(fields[1], "0x"), 16, 64)
+		if err != nil {
+			continue
+		}
+		if len(cur.Instrs) == 0 {
+			cur.Addr = addr
+			cur.Line = n
+		}
+		text := fields[3]
+		for {
+			prefix, rest, ok := strings.Cut(text, "; ")
+			if !ok {
+				break
+			}
+			cur.Instrs = append(cur.Instrs, Instr{Offset: int64(addr - cur.Addr), File: file, Line: n, Op: prefix})
+			text = rest
+		}
+		op, args, _ := strings.Cut(text, " ")
+		cur.Instrs = append(cur.Instrs, Instr{Offset: int64(addr - cur.Addr), File: file, Line: n, Op: op, Args: strings.TrimSpace(args)})
+		cur.Size = int64(addr-cur.Addr) + int64(len(fields[2])/2)
+	}
+	return funcs, sc.Err()
+}
@@ -0,0 +1,104 @@
+// Package calibrate measures the by-value size sweep on the current machine
+// and derives a threshold for the analyzers from it.
+package calibrate
+
+import (
+	"fmt"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+	"github.com/rohanchauhan02/valuevspointer/internal/stats"
+)
+
+// Margin is the fraction of the pointer time by which passing by value must
+// be slower, at 95% confidence, to count as measurably slower. It keeps
+// timer noise at the small sizes from producing a crossover.
+const Margin = 0.10
+
+// Measure runs the "sweep" scenarios count times, interleaving the value
+// and pointer variants so drift affects both alike. progress, if not nil,
+// is called after every run.
+func Measure(count int, progress func(scenario.Result)) ([]config.Point, error) {
+	type pair struct{ value, pointer *scenario.Scenario }
+	var sizes []int64
+	pairs := make(map[int64]*pair)
+	for _, s := range scenario.Group("sweep") {
+		p := pairs[s.Size]
+		if p == nil {
+			p = new(pair)
+			pairs[s.Size] = p
+			sizes = append(sizes, s.Size)
+		}
+		switch s.Variant {
+		case "value":
+			p.value = s
+		case "pointer":
+			p.pointer = s
+		}
+	}
+	if len(sizes) == 0 {
+		return nil, fmt.Errorf("no sweep scenarios registered")
+	}
+
+	value := make(map[int64][]float64)
+	diff := make(map[int64][]float64)
+	for range count {
+		for _, size := range sizes {
+			p := pairs[size]
+			if p.value == nil || p.pointer == nil {
+				return nil, fmt.Errorf("sweep size %d lacks a value or pointer variant", size)
+			}
+			v, ptr := scenario.Run(p.value), scenario.Run(p.pointer)
+			if progress != nil {
+				progress(v)
+				progress(ptr)
+			}
+			value[size] = append(value[size], v.NsPerOp)
+			diff[size] = append(diff[size], v.NsPerOp-ptr.NsPerOp)
+		}
+	}
+
+	points := make([]config.Point, len(sizes))
+	for i, size := range sizes {
+		lo, hi := stats.CI95(diff[size])
+		v := stats.Mean(value[size])
+		points[i] = config.Point{
+			Size:      size,
+			ValueNs:   v,
+			PointerNs: v - stats.Mean(diff[size]),
+			DiffLo:    lo,
+			DiffHi:    hi,
+		}
+	}
+	return points, nil
+}
+
+// Recommend derives a threshold from points sorted by size. The crossover
+// is the smallest size that is measurably slower by value than by pointer
+// and is not followed by a size that is measurably not slower; sizes whose
+// interval straddles the margin do not count either way. The threshold is
+// the size measured just below the crossover, or the largest measured size
+// when there is no crossover.
+func Recommend(points []config.Point) (threshold int64, crossover *config.Point) {
+	if len(points) == 0 {
+		return config.DefaultThreshold, nil
+	}
+	at := len(points)
+	for i := len(points) - 1; i >= 0; i-- {
+		p := points[i]
+		margin := Margin * p.PointerNs
+		if p.DiffHi <= margin {
+			break
+		}
+		if p.DiffLo > margin {
+			at = i
+		}
+	}
+	switch at {
+	case len(points):
+		return points[len(points)-1].Size, nil
+	case 0:
+		return 0, &points[0]
+	}
+	return points[at-1].Size, &points[at]
+}
@@ -0,0 +1,69 @@
+package calibrate
+
+import (
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+)
+
+func point(size int64, ptr, lo, hi float64) config.Point {
+	return config.Point{Size: size, PointerNs: ptr, ValueNs: ptr + (lo+hi)/2, DiffLo: lo, DiffHi: hi}
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []config.Point
+		threshold int64
+		crossover int64
+	}{
+		{
+			name:      "crossover",
+			points:    []config.Point{point(64, 2, -0.1, 0.1), point(128, 2, -0.1, 0.1), point(256, 2, 5, 6), point(512, 2, 9, 10)},
+			threshold: 128,
+			crossover: 256,
+		},
+		{
+			name:      "noise below margin",
+			points:    []config.Point{point(64, 10, 0.5, 0.8), point(128, 10, 0.6, 0.9), point(256, 10, 4, 5)},
+			threshold: 128,
+			crossover: 256,
+		},
+		{
+			name:      "isolated blip",
+			points:    []config.Point{point(64, 2, 3, 4), point(128, 2, -0.1, 0.1), point(256, 2, 5, 6)},
+			threshold: 128,
+			crossover: 256,
+		},
+		{
+			name:      "inconclusive large size",
+			points:    []config.Point{point(64, 2, -0.1, 0.1), point(128, 2, 1, 2), point(256, 2, -30, 300), point(512, 2, 9, 10)},
+			threshold: 64,
+			crossover: 128,
+		},
+		{
+			name:      "never slower",
+			points:    []config.Point{point(64, 2, -0.1, 0.1), point(128, 2, -0.1, 0.1)},
+			threshold: 128,
+		},
+		{
+			name:      "always slower",
+			points:    []config.Point{point(64, 2, 3, 4), point(128, 2, 4, 5)},
+			threshold: 0,
+			crossover: 64,
+		},
+	}
+	for _, tt := range tests {
+		threshold, crossover := Recommend(tt.points)
+		if threshold != tt.threshold {
+			t.Errorf("%s: threshold = %d, want %d", tt.name, threshold, tt.threshold)
+		}
+		var got int64
+		if crossover != nil {
+			got = crossover.Size
+		}
+		if got != tt.crossover {
+			t.Errorf("%s: crossover = %d, want %d", tt.name, got, tt.crossover)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package check
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// AnyArg reports values larger than the configured threshold passed to a
+// variadic ...any parameter, as in fmt.Printf, log.Printf or slog.Info.
+// Boxing a value into an interface copies it to the heap at the call site,
+// even when the callee, such as a logger below its level, never looks at it.
+var AnyArg = &Check{
+	Name: "anyarg",
+	Doc:  "large values boxed for variadic ...any parameters such as fmt and log arguments",
+	Run:  runAnyArg,
+}
+
+func runAnyArg(p *Pass) {
+	for _, f := range p.Pkg.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || call.Ellipsis.IsValid() {
+				return true
+			}
+			t := p.Pkg.Info.TypeOf(call.Fun)
+			if t == nil {
+				return true
+			}
+			sig, ok := t.Underlying().(*types.Signature)
+			if !ok || !sig.Variadic() {
+				return true
+			}
+			last := sig.Params().Len() - 1
+			elem := sig.Params().At(last).Type().(*types.Slice).Elem()
+			if !types.IsInterface(elem) {
+				return true
+			}
+			for _, arg := range call.Args[min(last, len(call.Args)):] {
+				t := p.Pkg.Info.TypeOf(arg)
+				if t == nil || types.IsInterface(t) {
+					continue
+				}
+				ok, size, threshold := p.Exceeds(t)
+				if !ok {
+					continue
+				}
+				p.Reportf(arg.Pos(), size, threshold, "argument %s of type %s is copied into an interface for %s (%d bytes > %d); pass a pointer",
+					types.ExprString(arg), types.TypeString(t, types.RelativeTo(p.Pkg.Types)), callee(p, call), size, threshold)
+			}
+			return true
+		})
+	}
+}
+
+// callee names the function called, e.g. "fmt.Printf" or
+// "(*log/slog.Logger).Info".
+func callee(p *Pass, call *ast.CallExpr) string {
+	var id *ast.Ident
+	switch fn := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fn
+	case *ast.SelectorExpr:
+		id = fn.Sel
+	}
+	if id != nil {
+		if fn, ok := p.Pkg.Info.Uses[id].(*types.Func); ok {
+			return fn.FullName()
+		}
+	}
+	return types.ExprString(call.Fun)
+}
@@ -0,0 +1,123 @@
+// Package check implements the static checks run by cmd/copycheck. Every check
+// reads its size thresholds from the shared configuration and honours the
+// suppression directives described in package config.
+package check
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// A Check is a single named analysis.
+type Check struct {
+	Name string
+	Doc  string
+	Run  func(*Pass)
+}
+
+// All lists every check in the order they run.
+var All = []*Check{Param, LoopAddr, Conv, AnyArg}
+
+// Lookup returns the checks named in the comma separated list. An empty list
+// selects every check.
+func Lookup(list string) ([]*Check, error) {
+	if list == "" {
+		return All, nil
+	}
+	var checks []*Check
+	for _, name := range strings.Split(list, ",") {
+		c := find(name)
+		if c == nil {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+func find(name string) *Check {
+	for _, c := range All {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Finding is a single reported problem.
+type Finding struct {
+	Pos       token.Position `json:"pos"`
+	Check     string         `json:"check"`
+	Message   string         `json:"message"`
+	Size      int64          `json:"size"`
+	Threshold int64          `json:"threshold"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.Pos, f.Message, f.Check)
+}
+
+// Pass is the state handed to a check while it runs on one package.
+type Pass struct {
+	Pkg    *load.Package
+	Config *config.Config
+
+	check    string
+	findings []Finding
+}
+
+// Exceeds reports whether a value of type t is larger than the configured
+// threshold for it, returning its size and the threshold. Types whose size
+// depends on a type parameter never exceed it.
+func (p *Pass) Exceeds(t types.Type) (bool, int64, int64) {
+	size, ok := p.Pkg.Sizeof(t)
+	if !ok {
+		return false, 0, 0
+	}
+	ok, threshold := p.Config.Exceeds(p.Pkg.Path, load.TypeName(t), size)
+	return ok, size, threshold
+}
+
+// Reportf records a finding at pos.
+func (p *Pass) Reportf(pos token.Pos, size, threshold int64, format string, args ...any) {
+	p.findings = append(p.findings, Finding{
+		Pos:       p.Pkg.Fset.Position(pos),
+		Check:     p.check,
+		Message:   fmt.Sprintf(format, args...),
+		Size:      size,
+		Threshold: threshold,
+	})
+}
+
+// Run runs checks on pkg and returns the findings that are not suppressed,
+// sorted by position.
+func Run(pkg *load.Package, cfg *config.Config, checks []*Check) []Finding {
+	sup := config.Suppress(pkg.Fset, pkg.Files)
+	var out []Finding
+	for _, c := range checks {
+		p := &Pass{Pkg: pkg, Config: cfg, check: c.Name}
+		c.Run(p)
+		for _, f := range p.findings {
+			if !sup.Has(f.Pos, f.Check) {
+				out = append(out, f)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].Pos, out[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return out
+}
@@ -0,0 +1,137 @@
+package check
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// runTest runs c on testdata/<name> and compares the findings with the
+// `// want "regexp"` comments in the source. A nil cfg means a threshold of
+// 128 bytes.
+func runTest(t *testing.T, c *Check, name string, cfg *config.Config) {
+	t.Helper()
+	pkg, err := load.Dir(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg == nil {
+		threshold := int64(128)
+		cfg = &config.Config{Threshold: &threshold}
+	}
+
+	type key struct {
+		file string
+		line int
+	}
+	want := make(map[key]*regexp.Regexp)
+	for _, f := range pkg.Files {
+		for _, cg := range f.Comments {
+			for _, cm := range cg.List {
+				text, ok := strings.CutPrefix(cm.Text, "// want ")
+				if !ok {
+					continue
+				}
+				pat, err := strconv.Unquote(strings.TrimSpace(text))
+				if err != nil {
+					t.Fatalf("%s: bad want comment: %v", pkg.Fset.Position(cm.Pos()), err)
+				}
+				p := pkg.Fset.Position(cm.Pos())
+				want[key{p.Filename, p.Line}] = regexp.MustCompile(pat)
+			}
+		}
+	}
+
+	for _, f := range Run(pkg, cfg, []*Check{c}) {
+		k := key{f.Pos.Filename, f.Pos.Line}
+		re, ok := want[k]
+		if !ok {
+			t.Errorf("unexpected finding: %v", f)
+			continue
+		}
+		if !re.MatchString(f.Message) {
+			t.Errorf("%v: message does not match %q", f, re)
+		}
+		delete(want, k)
+	}
+	for k, re := range want {
+		t.Errorf("%s:%d: missing finding matching %q", k.file, k.line, re)
+	}
+}
+
+func TestParam(t *testing.T) {
+	threshold := int64(128)
+	cfg := &config.Config{
+		Threshold: &threshold,
+		Types:     map[string]int64{"github.com/rohanchauhan02/valuevspointer/internal/check/testdata/param.Exempt": -1},
+	}
+	runTest(t, Param, "param", cfg)
+}
+
+func TestLookup(t *testing.T) {
+	if _, err := Lookup("param,nope"); err == nil {
+		t.Error("Lookup accepted an unknown check")
+	}
+	checks, err := Lookup("")
+	if err != nil || len(checks) != len(All) {
+		t.Errorf("Lookup(\"\") = %d checks, %v; want all", len(checks), err)
+	}
+}
+
+func TestLoopAddr(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the compiler's escape analysis")
+	}
+	runTest(t, LoopAddr, "loopaddr", nil)
+}
+
+func TestConv(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the compiler's escape analysis")
+	}
+	runTest(t, Conv, "conv", nil)
+}
+
+// TestConvAssembly confirms against the compiler's output that the string
+// conversions Conv reports in testdata/conv call the runtime to copy and
+// that those it leaves out do not. The []byte conversions there convert
+// constants, which the compiler copies inline.
+func TestConvAssembly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles testdata")
+	}
+	funcs, err := asm.Compile(filepath.Join("testdata", "conv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copies := make(map[string]bool)
+	for _, f := range funcs {
+		name := f.Name[strings.LastIndex(f.Name, ".")+1:]
+		for _, c := range f.Calls() {
+			switch c.Target() {
+			case "runtime.slicebytetostring":
+				copies[name] = true
+			}
+		}
+	}
+	for _, name := range []string{"Copy", "Part", "Store", "Incr"} {
+		if !copies[name] {
+			t.Errorf("%s: reported, but the compiler does not copy", name)
+		}
+	}
+	for _, name := range []string{"Len", "Lookup", "LookupOK", "Compare", "Concat", "Switch"} {
+		if copies[name] {
+			t.Errorf("%s: not reported, but the compiler copies", name)
+		}
+	}
+}
+
+func TestAnyArg(t *testing.T) {
+	runTest(t, AnyArg, "anyarg", nil)
+}
@@ -0,0 +1,222 @@
+package check
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/escape"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// Conv reports conversions that copy more bytes than the configured
+// threshold: string(b) and []byte(s), whose length is known when they slice
+// an array or convert a constant, and [N]T(s). Conversions the compiler
+// performs without a copy are left out: the operand of len; string(b) as a
+// map key in a lookup, an operand of a comparison or concatenation, or a
+// switch tag; []byte(s) ranged over, or, as its escape analysis reports,
+// neither written to nor escaping.
+var Conv = &Check{
+	Name: "conv",
+	Doc:  "conversions copying large buffers: string(b), []byte(s), [N]T(s)",
+	Run:  runConv,
+}
+
+// A conversion is a []byte(s) conversion that copies unless the compiler
+// finds it can share the string's memory.
+type conversion struct {
+	call            *ast.CallExpr
+	size, threshold int64
+}
+
+func runConv(p *Pass) {
+	var pending []conversion
+	for _, f := range p.Pkg.Files {
+		var stack []ast.Node
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			stack = append(stack, n)
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 || !p.Pkg.Info.Types[call.Fun].IsType() {
+				return true
+			}
+			to, from := p.Pkg.Info.TypeOf(call), p.Pkg.Info.TypeOf(call.Args[0])
+			if to == nil || from == nil {
+				return true
+			}
+			switch {
+			case isString(to) && isBytes(from):
+				size, known := knownLen(p.Pkg, call.Args[0])
+				if !known || sharedString(p.Pkg, stack) {
+					break
+				}
+				if ok, threshold := p.Config.Exceeds(p.Pkg.Path, load.TypeName(to), size); ok {
+					p.Reportf(call.Pos(), size, threshold, "conversion %s copies into a new string (%d bytes > %d)",
+						types.ExprString(call), size, threshold)
+				}
+			case isBytes(to) && isString(from):
+				size, known := knownLen(p.Pkg, call.Args[0])
+				if !known {
+					break
+				}
+				switch e := enclosing(stack).(type) {
+				case *ast.RangeStmt:
+					if ast.Unparen(e.X) == call {
+						return true
+					}
+				case *ast.CallExpr:
+					if isLen(p.Pkg, e) {
+						return true
+					}
+				}
+				if ok, threshold := p.Config.Exceeds(p.Pkg.Path, load.TypeName(to), size); ok {
+					pending = append(pending, conversion{call, size, threshold})
+				}
+			default:
+				arr, ok := to.Underlying().(*types.Array)
+				if _, slice := from.Underlying().(*types.Slice); !ok || !slice {
+					break
+				}
+				if ok, size, threshold := p.Exceeds(to); ok {
+					p.Reportf(call.Pos(), size, threshold, "conversion %s copies the slice into an array (%d bytes > %d); convert to %s to share its memory",
+						types.ExprString(call), size, threshold, types.TypeString(types.NewPointer(arr), types.RelativeTo(p.Pkg.Types)))
+				}
+			}
+			return true
+		})
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	diags, err := escape.Run(p.Pkg.Dir)
+	for _, c := range pending {
+		start, end := p.Pkg.Fset.Position(c.call.Pos()), p.Pkg.Fset.Position(c.call.End())
+		shared := false
+		for _, d := range diags {
+			if d.Kind == escape.ZeroCopy && d.File == start.Filename && d.Line == start.Line && d.Col >= start.Column && d.Col < end.Column {
+				shared = true
+			}
+		}
+		switch {
+		case err != nil:
+			p.Reportf(c.call.Pos(), c.size, c.threshold, "conversion %s may copy into a new slice (%d bytes > %d; escape analysis failed: %v)",
+				types.ExprString(c.call), c.size, c.threshold, err)
+		case !shared:
+			p.Reportf(c.call.Pos(), c.size, c.threshold, "conversion %s copies into a new slice (%d bytes > %d)",
+				types.ExprString(c.call), c.size, c.threshold)
+		}
+	}
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+func isBytes(t types.Type) bool {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
+
+// knownLen returns the length of a constant string or of a slice of an array
+// with constant bounds.
+func knownLen(pkg *load.Package, x ast.Expr) (int64, bool) {
+	x = ast.Unparen(x)
+	if tv := pkg.Info.Types[x]; tv.Value != nil && tv.Value.Kind() == constant.String {
+		return int64(len(constant.StringVal(tv.Value))), true
+	}
+	s, ok := x.(*ast.SliceExpr)
+	if !ok {
+		return 0, false
+	}
+	t := pkg.Info.TypeOf(s.X).Underlying()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem().Underlying()
+	}
+	arr, ok := t.(*types.Array)
+	if !ok {
+		return 0, false
+	}
+	bound := func(e ast.Expr, def int64) (int64, bool) {
+		if e == nil {
+			return def, true
+		}
+		v := pkg.Info.Types[e].Value
+		if v == nil {
+			return 0, false
+		}
+		return constant.Int64Val(constant.ToInt(v))
+	}
+	lo, ok1 := bound(s.Low, 0)
+	hi, ok2 := bound(s.High, arr.Len())
+	return hi - lo, ok1 && ok2
+}
+
+// sharedString reports whether the string(b) conversion last on stack is
+// used where the compiler reads the bytes in place.
+func sharedString(pkg *load.Package, stack []ast.Node) bool {
+	conv := stack[len(stack)-1]
+	switch p := enclosing(stack).(type) {
+	case *ast.IndexExpr:
+		if _, ok := pkg.Info.TypeOf(p.X).Underlying().(*types.Map); !ok || ast.Unparen(p.Index) != conv {
+			return false
+		}
+		// A lookup; storing into the map keeps a copy of the key.
+		i := len(stack) - 1
+		for stack[i] != p {
+			i--
+		}
+		switch s := enclosing(stack[:i+1]).(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range s.Lhs {
+				if ast.Unparen(lhs) == p {
+					return false
+				}
+			}
+		case *ast.IncDecStmt:
+			return false
+		}
+		return true
+	case *ast.BinaryExpr:
+		switch p.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.ADD:
+			return true
+		}
+	case *ast.SwitchStmt:
+		return ast.Unparen(p.Tag) == conv
+	case *ast.CallExpr:
+		return isLen(pkg, p)
+	}
+	return false
+}
+
+// isLen reports whether call calls the builtin len, which the compiler
+// applies to the operand of a conversion instead.
+func isLen(pkg *load.Package, call *ast.CallExpr) bool {
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	b, ok := pkg.Info.Uses[id].(*types.Builtin)
+	return ok && b.Name() == "len"
+}
+
+// enclosing returns the innermost node of stack, other than parentheses,
+// that encloses the last one.
+func enclosing(stack []ast.Node) ast.Node {
+	for i := len(stack) - 2; i >= 0; i-- {
+		if _, ok := stack[i].(*ast.ParenExpr); !ok {
+			return stack[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package check
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"go/version"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/escape"
+)
+
+// LoopAddr reports loop variables larger than the configured threshold whose
+// address escapes. Since Go 1.22 every iteration has its own loop variables,
+// so the compiler moves a fresh copy to the heap on every iteration. Whether
+// the address escapes is left to the compiler's escape analysis, which runs
+// only when a file at language version 1.22 or later takes the address of a
+// large loop variable.
+var LoopAddr = &Check{
+	Name: "loopaddr",
+	Doc:  "escaping addresses of large per-iteration loop variables",
+	Run:  runLoopAddr,
+}
+
+// A loopVar is a large loop variable whose address is taken.
+type loopVar struct {
+	id        *ast.Ident
+	how       string // how its address is taken
+	element   bool   // a range variable over a slice or array
+	size      int64
+	threshold int64
+}
+
+func runLoopAddr(p *Pass) {
+	var vars []loopVar
+	for _, f := range p.Pkg.Files {
+		if v := p.Pkg.Info.FileVersions[f]; v == "" || version.Compare(v, "go1.22") < 0 {
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.RangeStmt:
+				if n.Tok != token.DEFINE {
+					break
+				}
+				element := false
+				switch t := p.Pkg.Info.TypeOf(n.X).Underlying().(type) {
+				case *types.Slice, *types.Array:
+					element = true
+				case *types.Pointer:
+					_, element = t.Elem().Underlying().(*types.Array)
+				}
+				for _, e := range []ast.Expr{n.Key, n.Value} {
+					vars = addrTaken(p, vars, e, n.Body, element && e == n.Value)
+				}
+			case *ast.ForStmt:
+				init, ok := n.Init.(*ast.AssignStmt)
+				if !ok || init.Tok != token.DEFINE {
+					break
+				}
+				for _, e := range init.Lhs {
+					vars = addrTaken(p, vars, e, n, false)
+				}
+			}
+			return true
+		})
+	}
+	if len(vars) == 0 {
+		return
+	}
+
+	type pos struct {
+		file      string
+		line, col int
+	}
+	moved := make(map[pos]bool)
+	diags, err := escape.Run(p.Pkg.Dir)
+	for _, d := range diags {
+		if d.Kind == escape.MovedToHeap {
+			moved[pos{d.File, d.Line, d.Col}] = true
+		}
+	}
+	for _, v := range vars {
+		at := p.Pkg.Fset.Position(v.id.Pos())
+		t := types.TypeString(p.Pkg.Info.Defs[v.id].Type(), types.RelativeTo(p.Pkg.Types))
+		hint := ""
+		if v.element {
+			hint = "; take the address of the element instead"
+		}
+		switch {
+		case err != nil:
+			p.Reportf(v.id.Pos(), v.size, v.threshold, "loop variable %s of type %s may escape through %s, allocating a copy per iteration (%d bytes > %d; escape analysis failed: %v)%s",
+				v.id.Name, t, v.how, v.size, v.threshold, err, hint)
+		case moved[pos{at.Filename, at.Line, at.Column}]:
+			p.Reportf(v.id.Pos(), v.size, v.threshold, "loop variable %s of type %s escapes through %s, allocating a copy per iteration (%d bytes > %d)%s",
+				v.id.Name, t, v.how, v.size, v.threshold, hint)
+		}
+	}
+}
+
+// addrTaken appends e to vars if it declares a large variable whose address
+// is taken in body: explicitly, by calling a method with a pointer receiver,
+// or by capturing it in a function literal.
+func addrTaken(p *Pass, vars []loopVar, e ast.Expr, body ast.Node, element bool) []loopVar {
+	id, ok := e.(*ast.Ident)
+	if !ok || id.Name == "_" {
+		return vars
+	}
+	obj := p.Pkg.Info.Defs[id]
+	if obj == nil {
+		return vars
+	}
+	large, size, threshold := p.Exceeds(obj.Type())
+	if !large {
+		return vars
+	}
+	how := ""
+	ast.Inspect(body, func(n ast.Node) bool {
+		if how != "" {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && rootedAt(p, n.X, obj) {
+				how = "its address"
+			}
+		case *ast.SelectorExpr:
+			sel := p.Pkg.Info.Selections[n]
+			if sel != nil && sel.Kind() == types.MethodVal && !sel.Indirect() && rootedAt(p, n.X, obj) {
+				if _, ptr := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer); ptr {
+					how = "the pointer receiver of " + n.Sel.Name
+				}
+			}
+		case *ast.FuncLit:
+			ast.Inspect(n.Body, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && p.Pkg.Info.Uses[id] == obj {
+					how = "a function literal capturing it"
+				}
+				return how == ""
+			})
+		}
+		return true
+	})
+	if how == "" {
+		return vars
+	}
+	return append(vars, loopVar{id: id, how: how, element: element, size: size, threshold: threshold})
+}
+
+// rootedAt reports whether x is obj or a field or array element stored in
+// it.
+func rootedAt(p *Pass, x ast.Expr, obj types.Object) bool {
+	for {
+		switch e := x.(type) {
+		case *ast.ParenExpr:
+			x = e.X
+		case *ast.Ident:
+			return p.Pkg.Info.Uses[e] == obj
+		case *ast.SelectorExpr:
+			if _, ptr := p.Pkg.Info.TypeOf(e.X).Underlying().(*types.Pointer); ptr {
+				return false
+			}
+			x = e.X
+		case *ast.IndexExpr:
+			if _, arr := p.Pkg.Info.TypeOf(e.X).Underlying().(*types.Array); !arr {
+				return false
+			}
+			x = e.X
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package check
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// Param reports receivers, parameters and results passed by value whose size
+// exceeds the configured threshold.
+var Param = &Check{
+	Name: "param",
+	Doc:  "large receivers, parameters and results passed by value",
+	Run:  runParam,
+}
+
+func runParam(p *Pass) {
+	for _, f := range p.Pkg.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				paramFields(p, n.Recv, "receiver")
+				paramFields(p, n.Type.Params, "parameter")
+				paramFields(p, n.Type.Results, "result")
+			case *ast.FuncLit:
+				paramFields(p, n.Type.Params, "parameter")
+				paramFields(p, n.Type.Results, "result")
+			}
+			return true
+		})
+	}
+}
+
+func paramFields(p *Pass, fields *ast.FieldList, kind string) {
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			continue
+		}
+		t := p.Pkg.Info.TypeOf(field.Type)
+		if t == nil {
+			continue
+		}
+		if _, ok := t.Underlying().(*types.Pointer); ok {
+			continue
+		}
+		ok, size, threshold := p.Exceeds(t)
+		if !ok {
+			continue
+		}
+		if len(field.Names) == 0 {
+			p.Reportf(field.Pos(), size, threshold, "%s of type %s passed by value (%d bytes > %d)",
+				kind, types.TypeString(t, types.RelativeTo(p.Pkg.Types)), size, threshold)
+			continue
+		}
+		for _, name := range field.Names {
+			p.Reportf(name.Pos(), size, threshold, "%s %s of type %s passed by value (%d bytes > %d)",
+				kind, name.Name, types.TypeString(t, types.RelativeTo(p.Pkg.Types)), size, threshold)
+		}
+	}
+}
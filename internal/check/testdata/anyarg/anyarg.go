@@ -0,0 +1,36 @@
+package anyarg
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+type Big struct{ Buf [256]byte }
+
+type Small struct{ A, B int64 }
+
+func logf(format string, args ...any) {}
+
+type Logf func(format string, args ...any)
+
+func sum(n ...int) int { return len(n) }
+
+func Calls(b Big, s Small, err error, list []any, printf Logf) {
+	fmt.Printf("%v %v\n", b, s) // want `argument b of type Big is copied into an interface for fmt.Printf \(256 bytes > 128\); pass a pointer`
+	fmt.Printf("%v\n", &b)
+	log.Println("big", b.Buf)             // want `argument b.Buf of type \[256\]byte is copied into an interface for log.Println`
+	slog.Info("msg", "big", b)            // want `for log/slog.Info`
+	slog.Default().Debug("msg", "big", b) // want `for \(\*log/slog.Logger\).Debug`
+	logf("%v", b)                         // want "for github.com/.*/anyarg.logf"
+	logf("%v", err)
+	logf("%v", list...)
+	printf("%v", b)    // want "for printf"
+	fmt.Println(Big{}) // want "argument Big{} of type Big"
+	sum(1, 2)
+	slog.Any("big", b)
+}
+
+func Suppressed(b Big) {
+	fmt.Println(b) //vvp:ignore anyarg
+}
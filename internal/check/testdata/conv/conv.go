@@ -0,0 +1,63 @@
+package conv
+
+type Big struct{ Buf [4096]byte }
+
+const long = "................................................................................................................................................................"
+
+var (
+	m     = map[string]int{}
+	str   string
+	bytes []byte
+	small [64]byte
+)
+
+func Copy(b *Big) { str = string(b.Buf[:]) } // want `conversion string\(b.Buf\[:\]\) copies into a new string \(4096 bytes > 128\)`
+
+func Part(b *Big) { str = string(b.Buf[1024:]) } // want `\(3072 bytes > 128\)`
+
+func Small() { str = string(small[:]) }
+
+func Unknown(b []byte) { str = string(b) }
+
+func Len(b *Big) int { return len(string(b.Buf[:])) } // no copy
+
+func Store(b *Big) { m[string(b.Buf[:])] = 1 } // want "copies into a new string"
+
+func Incr(b *Big) { m[string(b.Buf[:])]++ } // want "copies into a new string"
+
+func Lookup(b *Big) int { return m[string(b.Buf[:])] } // no copy
+
+func LookupOK(b *Big) bool { _, ok := m[(string(b.Buf[:]))]; return ok } // no copy
+
+func Compare(b *Big) bool { return string(b.Buf[:]) == "x" } // no copy
+
+func Concat(b *Big) string { return "a" + string(b.Buf[:]) } // no copy
+
+func Switch(b *Big) int { // no copy
+	switch string(b.Buf[:]) {
+	case "a":
+		return 1
+	}
+	return 0
+}
+
+func BytesCopy() { bytes = []byte(long) } // want `conversion \[\]byte\(long\) copies into a new slice \(160 bytes > 128\)`
+
+func BytesRange() (n int) { // no copy
+	for _, c := range []byte(long) {
+		n += int(c)
+	}
+	return n
+}
+
+func BytesRead() int { b := []byte(long); return int(b[3]) } // no copy
+
+func BytesLen() int { return len([]byte(long)) } // no copy
+
+func BytesWrite() byte { b := []byte(long); b[0] = 1; return b[1] } // want "copies into a new slice"
+
+func Array(s []byte) [4096]byte { return [4096]byte(s) } // want `conversion \[4096\]byte\(s\) copies the slice into an array \(4096 bytes > 128\); convert to \*\[4096\]byte to share its memory`
+
+func ArrayPointer(s []byte) *[4096]byte { return (*[4096]byte)(s) }
+
+func Suppressed(b *Big) { str = string(b.Buf[:]) } //vvp:ignore conv
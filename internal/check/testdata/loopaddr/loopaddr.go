@@ -0,0 +1,80 @@
+package loopaddr
+
+type Big struct{ Buf [256]byte }
+
+func (b *Big) Reset() { b.Buf = [256]byte{} }
+
+func (b Big) Len() int { return len(b.Buf) }
+
+type Small struct{ A, B int64 }
+
+var kept []*Big
+
+var keptSmall []*Small
+
+func keep(b *Big) { kept = append(kept, b) }
+
+func Range(list []Big) {
+	for _, b := range list { // want `loop variable b of type Big escapes through its address, allocating a copy per iteration \(256 bytes > 128\); take the address of the element instead`
+		keep(&b)
+	}
+}
+
+func Element(list []Big) {
+	for i := range list {
+		keep(&list[i])
+	}
+}
+
+func Field(list []Big) []*[256]byte {
+	var out []*[256]byte
+	for _, b := range list { // want "escapes through its address"
+		out = append(out, &b.Buf)
+	}
+	return out
+}
+
+func Local(list []Big) int {
+	n := 0
+	for _, b := range list {
+		p := &b
+		n += len(p.Buf)
+	}
+	return n
+}
+
+func Method(list []Big) int {
+	n := 0
+	for _, b := range list {
+		b.Reset()
+		n += b.Len()
+	}
+	return n
+}
+
+func ThreeClause(list []Big) {
+	for i, b := 0, (Big{}); i < len(list); i++ { // want `loop variable b of type Big escapes through its address, allocating a copy per iteration \(256 bytes > 128\)$`
+		b = list[i]
+		keep(&b)
+	}
+}
+
+func Closure(list []Big) []func() int {
+	var fs []func() int
+	for _, b := range list { // want "escapes through a function literal capturing it"
+		fs = append(fs, func() int { return b.Len() })
+	}
+	return fs
+}
+
+func SmallValue(list []Small) {
+	for _, s := range list {
+		keptSmall = append(keptSmall, &s)
+	}
+}
+
+func Suppressed(list []Big) {
+	for _, b := range list { //vvp:ignore loopaddr
+		keep(&b)
+	}
+}
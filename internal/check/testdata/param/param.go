@@ -0,0 +1,33 @@
+package param
+
+type Small struct{ A, B int64 }
+
+type Big struct{ Buf [256]byte }
+
+type Exempt struct{ Buf [256]byte }
+
+func ValueSmall(s Small) {}
+
+func ValueBig(b Big) {} // want "parameter b of type Big passed by value"
+
+func Pointer(b *Big) {}
+
+func Result() Big { return Big{} } // want "result of type Big passed by value"
+
+func (b Big) Method() {} // want "receiver b of type Big passed by value"
+
+func Exempted(e Exempt) {}
+
+func Array(a [512]byte) {} // want `parameter a of type \[512\]byte`
+
+func Variadic(b ...Big) {}
+
+var Lit = func(b Big) {} // want "parameter b of type Big"
+
+func Suppressed(b Big) {} //vvp:ignore param
+
+//vvp:ignore
+func SuppressedDoc(b Big) {}
+
+//vvp:ignore conv
+func OtherCheck(b Big) {} // want "parameter b of type Big"
@@ -0,0 +1,367 @@
+// Package claims checks statements in Markdown against measurements. A
+// claim is an HTML comment, invisible in the rendered document, placed
+// after the statement it backs:
+//
+//	Pass by value is significantly slower at 256KB.
+//	<!-- claim: sweep/value/262144 > 10 * sweep/pointer/262144 -->
+//
+// Each side of the comparison is a product of numbers and scenario
+// measurements. A scenario stands for its ns/op; "name[metric]" selects
+// another metric: "B/op", "allocs/op" or one the scenario reports itself,
+// such as "gcs/op". The comparisons are <, <=, >, >= and ~, which
+// holds when the sides are within 25% of each other. Conditions before the
+// colon restrict a claim to a platform or toolchain:
+//
+//	<!-- claim goarch=amd64 go>=1.22: ... -->
+package claims
+
+import (
+	"bufio"
+	"fmt"
+	"go/version"
+	"io"
+	"math"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+)
+
+// Tolerance is how far apart the sides of a ~ comparison may be, relative
+// to the larger one.
+const Tolerance = 0.25
+
+// A Claim is one annotation.
+type Claim struct {
+	File      string
+	Line      int
+	Statement string // the text the claim follows
+	Source    string // the assertion as written
+	Conds     []Cond
+	Left      Product
+	Op        string
+	Right     Product
+}
+
+// Cond restricts a claim to an environment, e.g. goarch=amd64 or go>=1.22.
+type Cond struct {
+	Key, Op, Value string
+}
+
+// Product is a product of factors.
+type Product []Factor
+
+// Factor is a number or the metric of a scenario.
+type Factor struct {
+	Number   float64
+	Scenario string
+	Metric   string
+}
+
+func (f Factor) String() string {
+	if f.Scenario == "" {
+		return strconv.FormatFloat(f.Number, 'g', -1, 64)
+	}
+	if f.Metric == "ns/op" {
+		return f.Scenario
+	}
+	return f.Scenario + "[" + f.Metric + "]"
+}
+
+var claimRE = regexp.MustCompile(`<!--\s*claim\b([^:]*):(.*?)-->`)
+
+// Parse reads the claims in a Markdown document. Claims in fenced code
+// blocks are examples and are skipped.
+func Parse(r io.Reader, file string) ([]*Claim, error) {
+	var (
+		claims []*Claim
+		prev   string
+		n      int
+		fence  string
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		n++
+		line := sc.Text()
+		if trimmed := strings.TrimSpace(line); fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			continue
+		} else if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		matches := claimRE.FindAllStringSubmatch(line, -1)
+		if matches == nil {
+			if text := strings.TrimSpace(line); text != "" {
+				prev = text
+			}
+			continue
+		}
+		statement := strings.TrimSpace(claimRE.ReplaceAllString(line, ""))
+		if statement == "" {
+			statement = prev
+		}
+		for _, m := range matches {
+			c, err := parseClaim(m[1], m[2])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", file, n, err)
+			}
+			c.File, c.Line, c.Statement = file, n, statement
+			claims = append(claims, c)
+		}
+	}
+	return claims, sc.Err()
+}
+
+var condRE = regexp.MustCompile(`^(goos|goarch|go)(=|!=|>=|<)(\S+)$`)
+
+func parseClaim(conds, expr string) (*Claim, error) {
+	c := &Claim{Source: strings.TrimSpace(expr)}
+	for _, f := range strings.Fields(conds) {
+		m := condRE.FindStringSubmatch(f)
+		if m == nil {
+			return nil, fmt.Errorf("invalid condition %q, want goos, goarch or go with =, !=, >= or <", f)
+		}
+		if m[1] != "go" && m[2] != "=" && m[2] != "!=" {
+			return nil, fmt.Errorf("invalid condition %q: %s is compared with = or !=", f, m[1])
+		}
+		c.Conds = append(c.Conds, Cond{m[1], m[2], m[3]})
+	}
+	toks := tokenize(c.Source)
+	var err error
+	for i, t := range toks {
+		switch t {
+		case "<", "<=", ">", ">=", "~":
+			if c.Op != "" {
+				return nil, fmt.Errorf("%q compares more than once", c.Source)
+			}
+			c.Op = t
+			if c.Left, err = product(toks[:i]); err != nil {
+				return nil, err
+			}
+			if c.Right, err = product(toks[i+1:]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c.Op == "" {
+		return nil, fmt.Errorf("%q has no comparison", c.Source)
+	}
+	return c, nil
+}
+
+// tokenize splits an assertion into comparisons, "*" and operands.
+func tokenize(s string) []string {
+	var toks []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '*' || c == '~':
+			toks = append(toks, string(c))
+			i++
+		case c == '<' || c == '>':
+			if i+1 < len(s) && s[i+1] == '=' {
+				toks = append(toks, s[i:i+2])
+				i += 2
+			} else {
+				toks = append(toks, string(c))
+				i++
+			}
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t*~<>", rune(s[j])) {
+				if s[j] == '[' {
+					for j < len(s) && s[j] != ']' {
+						j++
+					}
+				}
+				j++
+			}
+			j = min(j, len(s))
+			toks = append(toks, s[i:j])
+			i = j
+		}
+	}
+	return toks
+}
+
+func product(toks []string) (Product, error) {
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("missing operand")
+	}
+	var p Product
+	for i, t := range toks {
+		if i%2 == 1 {
+			if t != "*" {
+				return nil, fmt.Errorf("unexpected %q, operands are multiplied with *", t)
+			}
+			continue
+		}
+		f, err := factor(t)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, f)
+	}
+	if len(toks)%2 == 0 {
+		return nil, fmt.Errorf("missing operand after *")
+	}
+	return p, nil
+}
+
+func factor(t string) (Factor, error) {
+	if n, err := strconv.ParseFloat(t, 64); err == nil {
+		return Factor{Number: n}, nil
+	}
+	name, metric := t, "ns/op"
+	if i := strings.IndexByte(t, '['); i >= 0 {
+		if !strings.HasSuffix(t, "]") {
+			return Factor{}, fmt.Errorf("unterminated metric in %q", t)
+		}
+		name, metric = t[:i], t[i+1:len(t)-1]
+	}
+	if strings.Count(name, "/") != 2 || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return Factor{}, fmt.Errorf("%q is neither a number nor a scenario group/variant/size", t)
+	}
+	return Factor{Scenario: name, Metric: metric}, nil
+}
+
+// Scenarios returns the scenarios the claim measures.
+func (c *Claim) Scenarios() []string {
+	var names []string
+	for _, f := range append(append(Product{}, c.Left...), c.Right...) {
+		if f.Scenario != "" {
+			names = append(names, f.Scenario)
+		}
+	}
+	return names
+}
+
+// Applies reports whether the claim's conditions hold for the platform and
+// toolchain, and if not, which one fails.
+func (c *Claim) Applies(goos, goarch, goVersion string) (bool, string) {
+	for _, cond := range c.Conds {
+		var ok bool
+		switch cond.Key {
+		case "goos":
+			ok = (goos == cond.Value) == (cond.Op == "=")
+		case "goarch":
+			ok = (goarch == cond.Value) == (cond.Op == "=")
+		case "go":
+			cmp := compareVersions(goVersion, cond.Value)
+			switch cond.Op {
+			case "=":
+				ok = cmp == 0
+			case "!=":
+				ok = cmp != 0
+			case ">=":
+				ok = cmp >= 0
+			case "<":
+				ok = cmp < 0
+			}
+		}
+		if !ok {
+			return false, fmt.Sprintf("%s%s%s", cond.Key, cond.Op, cond.Value)
+		}
+	}
+	return true, ""
+}
+
+// compareVersions compares the toolchain version goVersion, as
+// runtime.Version reports it, with a condition's version like "1.22" or
+// "go1.22.3". A condition without a patch release matches every release of
+// its language version, so go1.22.3 equals 1.22. Development toolchains,
+// "devel go1.24-abcdef ...", are newer than any release.
+func compareVersions(goVersion, cond string) int {
+	if strings.HasPrefix(goVersion, "devel") {
+		return 1
+	}
+	if fields := strings.Fields(goVersion); len(fields) > 0 {
+		goVersion = fields[0] // without experiments such as "X:nocoverageredesign"
+	}
+	cond = "go" + strings.TrimPrefix(cond, "go")
+	if cond == version.Lang(cond) {
+		goVersion = version.Lang(goVersion)
+	}
+	return version.Compare(goVersion, cond)
+}
+
+// Verdict is the outcome of checking a claim.
+type Verdict struct {
+	Claim       *Claim
+	Skipped     string // the condition that does not apply, if any
+	Holds       bool
+	Left, Right float64
+	Detail      string // the measurements, e.g. "sweep/value/16 = 3.5"
+}
+
+// Check evaluates the claim with the given results by scenario name.
+func Check(c *Claim, results map[string]scenario.Result) (Verdict, error) {
+	v := Verdict{Claim: c}
+	if ok, cond := c.Applies(runtime.GOOS, runtime.GOARCH, runtime.Version()); !ok {
+		v.Skipped = cond
+		return v, nil
+	}
+	var details []string
+	eval := func(p Product) (float64, error) {
+		x := 1.0
+		for _, f := range p {
+			if f.Scenario == "" {
+				x *= f.Number
+				continue
+			}
+			r, ok := results[f.Scenario]
+			if !ok {
+				return 0, fmt.Errorf("no result for scenario %s", f.Scenario)
+			}
+			m, ok := metric(r, f.Metric)
+			if !ok {
+				return 0, fmt.Errorf("scenario %s does not report %s", f.Scenario, f.Metric)
+			}
+			details = append(details, fmt.Sprintf("%s = %.4g", f, m))
+			x *= m
+		}
+		return x, nil
+	}
+	var err error
+	if v.Left, err = eval(c.Left); err != nil {
+		return v, err
+	}
+	if v.Right, err = eval(c.Right); err != nil {
+		return v, err
+	}
+	switch c.Op {
+	case "<":
+		v.Holds = v.Left < v.Right
+	case "<=":
+		v.Holds = v.Left <= v.Right
+	case ">":
+		v.Holds = v.Left > v.Right
+	case ">=":
+		v.Holds = v.Left >= v.Right
+	case "~":
+		v.Holds = math.Abs(v.Left-v.Right) <= Tolerance*math.Max(math.Abs(v.Left), math.Abs(v.Right))
+	}
+	v.Detail = strings.Join(details, ", ")
+	return v, nil
+}
+
+func metric(r scenario.Result, name string) (float64, bool) {
+	switch name {
+	case "ns/op":
+		return r.NsPerOp, true
+	case "B/op":
+		return float64(r.BytesPerOp), true
+	case "allocs/op":
+		return float64(r.AllocsPerOp), true
+	}
+	v, ok := r.Extra[name]
+	return v, ok
+}
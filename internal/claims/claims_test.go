@@ -0,0 +1,117 @@
+package claims
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+)
+
+const doc = "# Title\n\n" +
+	"Copies are slow.\n" +
+	"<!-- claim: a/value/8 > 10 * a/pointer/8 -->\n" +
+	"Collections are counted. <!-- claim: b/value/8[gcs/op] >= 3 -->\n" +
+	"<!-- claim goarch=nonesuch: a/value/8 ~ a/pointer/8 -->\n" +
+	"```\n<!-- claim: c/value/8 < 1 -->\n```\n"
+
+func TestParse(t *testing.T) {
+	list, err := Parse(strings.NewReader(doc), "doc.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("got %d claims, want 3 (the fenced one is an example)", len(list))
+	}
+	c := list[0]
+	if c.Line != 4 || c.Statement != "Copies are slow." || c.Op != ">" || len(c.Left) != 1 || len(c.Right) != 2 || c.Right[0].Number != 10 || c.Right[1].Scenario != "a/pointer/8" {
+		t.Errorf("first claim = %+v", c)
+	}
+	if c := list[1]; c.Statement != "Collections are counted." || c.Left[0].Metric != "gcs/op" {
+		t.Errorf("second claim = %+v", c)
+	}
+	if c := list[2]; len(c.Conds) != 1 || c.Conds[0] != (Cond{"goarch", "=", "nonesuch"}) {
+		t.Errorf("third claim = %+v", c)
+	}
+
+	for _, bad := range []string{
+		"<!-- claim: a/value/8 -->",
+		"<!-- claim: a/value/8 > > 2 -->",
+		"<!-- claim: a/value/8 > 2 2 -->",
+		"<!-- claim: value > 2 -->",
+		"<!-- claim os=linux: a/b/8 > 2 -->",
+		"<!-- claim goarch>=amd64: a/b/8 > 2 -->",
+	} {
+		if _, err := Parse(strings.NewReader(bad), "bad.md"); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	list, err := Parse(strings.NewReader(doc), "doc.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]scenario.Result{
+		"a/value/8":   {NsPerOp: 30},
+		"a/pointer/8": {NsPerOp: 4},
+		"b/value/8":   {Extra: map[string]float64{"gcs/op": 5}},
+	}
+	v, err := Check(list[0], results)
+	if err != nil || v.Holds || v.Left != 30 || v.Right != 40 {
+		t.Errorf("Check(30 > 10*4) = %+v, %v", v, err)
+	}
+	results["a/value/8"] = scenario.Result{NsPerOp: 41}
+	if v, _ := Check(list[0], results); !v.Holds {
+		t.Errorf("Check(41 > 40) = %+v", v)
+	}
+	if v, err := Check(list[1], results); err != nil || !v.Holds {
+		t.Errorf("Check(gcs >= 3) = %+v, %v", v, err)
+	}
+	if v, err := Check(list[2], results); err != nil || v.Skipped != "goarch=nonesuch" {
+		t.Errorf("Check on another architecture = %+v, %v", v, err)
+	}
+	delete(results, "b/value/8")
+	if _, err := Check(list[1], results); err == nil {
+		t.Error("Check without a result succeeded")
+	}
+}
+
+func TestApplies(t *testing.T) {
+	c := &Claim{Conds: []Cond{{"go", ">=", "1.22"}, {"goos", "!=", "windows"}}}
+	for _, tt := range []struct {
+		goos, version string
+		want          bool
+	}{
+		{"linux", "go1.22.3", true},
+		{"linux", "go1.21.9", false},
+		{"linux", "go1.27.1", true},
+		{"windows", "go1.23.0", false},
+		{"linux", "devel go1.24-abcdef Tue Jan 7 10:00:00 2025 +0000", true},
+	} {
+		if ok, _ := c.Applies(tt.goos, "amd64", tt.version); ok != tt.want {
+			t.Errorf("Applies(%s, %s) = %v, want %v", tt.goos, tt.version, ok, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tt := range []struct {
+		version, cond string
+		want          int
+	}{
+		{"go1.22.3", "1.22", 0},
+		{"go1.22.3", "go1.22.3", 0},
+		{"go1.22.3", "1.22.4", -1},
+		{"go1.22rc1", "1.22", 0},
+		{"go1.22rc1", "1.22.0", -1},
+		{"go1.9.7", "1.22", -1},
+		{"go1.100", "1.22", 1},
+		{"go1.25.0 X:nocoverageredesign", "1.25", 0},
+		{"devel go1.24-abcdef Tue Jan 7 10:00:00 2025 +0000", "1.30", 1},
+	} {
+		if got := compareVersions(tt.version, tt.cond); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.version, tt.cond, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package config holds the copy size thresholds shared by every analyzer and
+// report in this module. The configuration lives in a JSON file checked into
+// the repository (see FileName) and is looked up from the analyzed directory
+// upwards, the same way the go command finds go.mod.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the configuration file.
+const FileName = "valuevspointer.json"
+
+// DefaultThreshold is the by-value size, in bytes, above which a value is
+// reported when no configuration says otherwise.
+const DefaultThreshold = 128
+
+// Config is the decoded configuration file.
+//
+// A threshold is the largest size in bytes that may be passed by value.
+// A negative threshold disables reporting altogether.
+type Config struct {
+	// Threshold applies to every package not matched by a rule.
+	Threshold *int64 `json:"threshold,omitempty"`
+
+	// Types overrides the threshold for specific types, keyed by the fully
+	// qualified type name, e.g. "github.com/rohanchauhan02/valuevspointer.BigStruct".
+	Types map[string]int64 `json:"types,omitempty"`
+
+	// Packages are per package pattern rules. When several rules match a
+	// package the last one wins.
+	Packages []Rule `json:"packages,omitempty"`
+
+	// Calibration records how Threshold was measured by cmd/calibrate.
+	Calibration *Calibration `json:"calibration,omitempty"`
+
+	path string
+}
+
+// Rule sets thresholds for the packages matching Pattern. Patterns use the go
+// command syntax: "..." matches any string and "a/..." also matches "a".
+type Rule struct {
+	Pattern   string           `json:"pattern"`
+	Threshold *int64           `json:"threshold,omitempty"`
+	Types     map[string]int64 `json:"types,omitempty"`
+}
+
+// Calibration is the justification for a measured threshold.
+type Calibration struct {
+	Date      time.Time `json:"date"`
+	GOOS      string    `json:"goos"`
+	GOARCH    string    `json:"goarch"`
+	GoVersion string    `json:"go_version"`
+	NumCPU    int       `json:"num_cpu"`
+	Count     int       `json:"count"`
+	Benchtime string    `json:"benchtime"`
+
+	// Crossover is the smallest size from which passing by value is
+	// measurably slower than passing by pointer, or 0 if it never is.
+	Crossover int64 `json:"crossover"`
+
+	// CrossoverCI is the 95% confidence interval, in ns/op, of the extra
+	// time passing by value costs at Crossover.
+	CrossoverCI [2]float64 `json:"crossover_ci"`
+
+	Points []Point `json:"points"`
+}
+
+// Point is the measurement of one size in a calibration sweep.
+type Point struct {
+	Size      int64   `json:"size"`
+	ValueNs   float64 `json:"value_ns"`
+	PointerNs float64 `json:"pointer_ns"`
+	DiffLo    float64 `json:"diff_lo"`
+	DiffHi    float64 `json:"diff_hi"`
+}
+
+// Default returns the configuration used when no file is found.
+func Default() *Config {
+	return &Config{}
+}
+
+// Load reads the configuration file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := new(Config)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, r := range c.Packages {
+		if r.Pattern == "" {
+			return nil, fmt.Errorf("%s: package rule without pattern", path)
+		}
+	}
+	c.path = path
+	return c, nil
+}
+
+// Find loads the nearest configuration file in dir or one of its parents.
+// It returns Default if there is none.
+func Find(dir string) (*Config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Default(), nil
+		}
+		dir = parent
+	}
+}
+
+// Path returns the file the configuration was loaded from, or "" for Default.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Save writes the configuration to path.
+func (c *Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	c.path = path
+	return nil
+}
+
+// For returns the threshold for values of type typeName used in the
+// package pkgPath. typeName may be empty for unnamed types.
+//
+// Type overrides of a matching rule take precedence over the top level type
+// overrides, which take precedence over the rule threshold, which takes
+// precedence over the top level threshold.
+func (c *Config) For(pkgPath, typeName string) int64 {
+	var rule *Rule
+	for i := range c.Packages {
+		if Match(c.Packages[i].Pattern, pkgPath) {
+			rule = &c.Packages[i]
+		}
+	}
+	if typeName != "" {
+		if rule != nil {
+			if t, ok := rule.Types[typeName]; ok {
+				return t
+			}
+		}
+		if t, ok := c.Types[typeName]; ok {
+			return t
+		}
+	}
+	if rule != nil && rule.Threshold != nil {
+		return *rule.Threshold
+	}
+	if c.Threshold != nil {
+		return *c.Threshold
+	}
+	return DefaultThreshold
+}
+
+// Exceeds reports whether a value of the given type and size should be
+// reported in package pkgPath, along with the threshold that was applied.
+func (c *Config) Exceeds(pkgPath, typeName string, size int64) (bool, int64) {
+	t := c.For(pkgPath, typeName)
+	return t >= 0 && size > t, t
+}
+
+// Match reports whether the import path matches the go command style pattern.
+func Match(pattern, path string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	ok, _ := regexp.MatchString("^"+re+"$", path)
+	return ok
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"example.com/a", "example.com/a", true},
+		{"example.com/a", "example.com/a/b", false},
+		{"example.com/a/...", "example.com/a", true},
+		{"example.com/a/...", "example.com/a/b/c", true},
+		{"example.com/a/...", "example.com/ab", false},
+		{"example.com/.../internal", "example.com/x/internal", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	data := `{
+		"threshold": 64,
+		"types": {"example.com/a.Big": 1024},
+		"packages": [
+			{"pattern": "example.com/a/...", "threshold": 256},
+			{"pattern": "example.com/a/hot", "threshold": 16, "types": {"example.com/a.Big": -1}}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Find(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Path() != path {
+		t.Fatalf("Path() = %q, want %q", c.Path(), path)
+	}
+
+	tests := []struct {
+		pkg, typ string
+		want     int64
+	}{
+		{"example.com/b", "", 64},
+		{"example.com/b", "example.com/a.Big", 1024},
+		{"example.com/a/x", "", 256},
+		{"example.com/a/x", "example.com/a.Big", 1024},
+		{"example.com/a/hot", "", 16},
+		{"example.com/a/hot", "example.com/a.Big", -1},
+	}
+	for _, tt := range tests {
+		if got := c.For(tt.pkg, tt.typ); got != tt.want {
+			t.Errorf("For(%q, %q) = %d, want %d", tt.pkg, tt.typ, got, tt.want)
+		}
+	}
+	if ok, _ := c.Exceeds("example.com/a/hot", "example.com/a.Big", 1<<20); ok {
+		t.Error("negative threshold should never be exceeded")
+	}
+	if got := Default().For("example.com/b", ""); got != DefaultThreshold {
+		t.Errorf("Default().For = %d, want %d", got, DefaultThreshold)
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	const src = `package p
+
+//vvp:ignore
+func A() {
+	_ = 1
+}
+
+func B() {
+	//vvp:ignore param demo only
+	_ = 1
+	_ = 2
+}
+
+//vvp:ignored
+func C() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Suppress(fset, []*ast.File{f})
+	tests := []struct {
+		line  int
+		check string
+		want  bool
+	}{
+		{5, "param", true},
+		{5, "conv", true},
+		{10, "param", true},
+		{10, "conv", false},
+		{11, "param", false},
+		{16, "param", false},
+	}
+	for _, tt := range tests {
+		pos := token.Position{Filename: "p.go", Line: tt.line}
+		if got := s.Has(pos, tt.check); got != tt.want {
+			t.Errorf("Has(line %d, %q) = %v, want %v", tt.line, tt.check, got, tt.want)
+		}
+	}
+}
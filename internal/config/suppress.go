@@ -0,0 +1,89 @@
+package config
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Directive is the comment that silences findings:
+//
+//	//vvp:ignore                           silences every check
+//	//vvp:ignore * demo code               the same, with a reason
+//	//vvp:ignore param,conv hot path only  silences the named checks only
+//
+// The first word is the check list; anything after it is free text. The
+// directive applies to its own line and the line below it; in the doc
+// comment of a function it applies to the whole function.
+const Directive = "//vvp:ignore"
+
+type span struct {
+	file       string
+	start, end int
+	checks     []string
+}
+
+// Suppressions records the directives found in a set of files.
+type Suppressions struct {
+	spans []span
+}
+
+// Suppress collects the directives in files.
+func Suppress(fset *token.FileSet, files []*ast.File) *Suppressions {
+	s := new(Suppressions)
+	for _, f := range files {
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				checks, ok := parseDirective(c.Text)
+				if !ok {
+					continue
+				}
+				p := fset.Position(c.Slash)
+				s.spans = append(s.spans, span{p.Filename, p.Line, p.Line + 1, checks})
+			}
+		}
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			for _, c := range fn.Doc.List {
+				if checks, ok := parseDirective(c.Text); ok {
+					start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+					s.spans = append(s.spans, span{start.Filename, start.Line, end.Line, checks})
+				}
+			}
+		}
+	}
+	return s
+}
+
+func parseDirective(text string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(text, Directive)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return nil, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || fields[0] == "*" {
+		return nil, true
+	}
+	return strings.Split(fields[0], ","), true
+}
+
+// Has reports whether a finding of the named check at pos is suppressed.
+func (s *Suppressions) Has(pos token.Position, check string) bool {
+	for _, sp := range s.spans {
+		if sp.file != pos.Filename || pos.Line < sp.start || pos.Line > sp.end {
+			continue
+		}
+		if len(sp.checks) == 0 {
+			return true
+		}
+		for _, c := range sp.checks {
+			if c == check {
+				return true
+			}
+		}
+	}
+	return false
+}
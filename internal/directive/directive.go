@@ -0,0 +1,192 @@
+// Package directive compiles a package once per directive variant of its
+// callees and compares the results: the frame of every callee and of the
+// functions calling it, whether each carries the stack check that calls
+// runtime.morestack, and whether the calls survive inlining.
+//
+// The variants are selected with build tags, as the scenario package does
+// with its callees_*.go files: no tag builds the callees with
+// //go:noinline, "directive_default" without directives and
+// "directive_nosplit" with //go:noinline and //go:nosplit.
+package directive
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// Modes are the directive variants, in the order they are reported.
+var Modes = []string{"noinline", "default", "nosplit"}
+
+// Tag returns the build tag selecting mode.
+func Tag(mode string) (string, error) {
+	for _, m := range Modes {
+		if m == mode {
+			return "directive_" + mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown directive variant %q (want one of %s)", mode, strings.Join(Modes, ", "))
+}
+
+// NosplitLimit is the stack the linker lets a chain of nosplit functions use
+// below the stack guard, without the race detector. The return address of
+// the call into the chain counts against it.
+const NosplitLimit = 800
+
+// Frame is a function as compiled in one variant.
+type Frame struct {
+	Compiled  bool  `json:"compiled"` // false when every call was inlined and no body was emitted
+	Frame     int64 `json:"frame"`
+	Nosplit   bool  `json:"nosplit"`
+	Morestack bool  `json:"morestack"` // the prologue calls runtime.morestack
+}
+
+// Callee is a function whose directives change between the variants.
+type Callee struct {
+	Name    string           `json:"name"` // symbol without the package prefix
+	Modes   map[string]Frame `json:"modes"`
+	Calls   map[string]int   `json:"calls"` // direct call sites in the package
+	Callers []Caller         `json:"callers"`
+}
+
+// Caller is a function that calls a callee in some variant.
+type Caller struct {
+	Name  string           `json:"name"`
+	Modes map[string]Frame `json:"modes"`
+}
+
+// Inlined reports whether the calls to c disappeared in mode, although the
+// callee is called in another variant.
+func (c *Callee) Inlined(mode string) bool {
+	return c.Calls[mode] == 0 && len(c.Callers) > 0
+}
+
+// NosplitLegal reports whether f fits below the stack guard on its own,
+// which the linker requires of a nosplit function; chains of nosplit calls
+// need more and are not followed.
+func NosplitLegal(f Frame, goarch string) bool {
+	return f.Frame+2*asm.ReturnAddr(goarch) <= NosplitLimit
+}
+
+// Compile builds the package in dir in every mode and returns the listings
+// by mode.
+func Compile(dir string, modes []string, gcflags ...string) (map[string][]*asm.Func, error) {
+	listings := make(map[string][]*asm.Func)
+	for _, mode := range modes {
+		tag, err := Tag(mode)
+		if err != nil {
+			return nil, err
+		}
+		funcs, err := asm.CompileTags(dir, []string{tag}, gcflags...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", mode, err)
+		}
+		listings[mode] = funcs
+	}
+	return listings, nil
+}
+
+// Marked returns the symbols of the functions of pkg declared with
+// //go:noinline or //go:nosplit, the callees of the variants in a default
+// build.
+func Marked(pkg *load.Package) map[string]bool {
+	marked := make(map[string]bool)
+	for sym, fn := range pkg.Funcs() {
+		if hasDirective(fn.Doc, "//go:noinline") || hasDirective(fn.Doc, "//go:nosplit") {
+			marked[sym] = true
+		}
+	}
+	return marked
+}
+
+func hasDirective(doc *ast.CommentGroup, directive string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if c.Text == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare matches the callees across the listings. A symbol is a callee
+// when its declaration, BaseSymbol of it, is in marked and it matches the
+// filter, if any; generic callees have one entry per shape. Callees are
+// sorted by name, callers by name.
+func Compare(listings map[string][]*asm.Func, prefix string, marked map[string]bool, filter *regexp.Regexp) []*Callee {
+	callees := make(map[string]*Callee)
+	isCallee := func(sym string) bool {
+		return strings.HasPrefix(sym, prefix) && marked[load.BaseSymbol(sym)] &&
+			(filter == nil || filter.MatchString(strings.TrimPrefix(sym, prefix)))
+	}
+	callee := func(sym string) *Callee {
+		name := strings.TrimPrefix(sym, prefix)
+		c := callees[name]
+		if c == nil {
+			c = &Callee{Name: name, Modes: make(map[string]Frame), Calls: make(map[string]int)}
+			callees[name] = c
+		}
+		return c
+	}
+	callers := make(map[*Callee]map[string]bool)
+	for mode, funcs := range listings {
+		for _, f := range funcs {
+			if isCallee(f.Name) {
+				callee(f.Name).Modes[mode] = frameOf(f)
+			}
+			for _, in := range f.Calls() {
+				if !isCallee(in.Target()) {
+					continue
+				}
+				c := callee(in.Target())
+				c.Calls[mode]++
+				if callers[c] == nil {
+					callers[c] = make(map[string]bool)
+				}
+				callers[c][f.Name] = true
+			}
+		}
+	}
+
+	byName := make(map[string]map[string]*asm.Func)
+	for mode, funcs := range listings {
+		byName[mode] = make(map[string]*asm.Func)
+		for _, f := range funcs {
+			byName[mode][f.Name] = f
+		}
+	}
+	var list []*Callee
+	for _, c := range callees {
+		for name := range callers[c] {
+			cl := Caller{Name: strings.TrimPrefix(name, prefix), Modes: make(map[string]Frame)}
+			for mode := range listings {
+				if f := byName[mode][name]; f != nil {
+					cl.Modes[mode] = frameOf(f)
+				}
+			}
+			c.Callers = append(c.Callers, cl)
+		}
+		sort.Slice(c.Callers, func(i, j int) bool { return c.Callers[i].Name < c.Callers[j].Name })
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func frameOf(f *asm.Func) Frame {
+	fr := Frame{Compiled: true, Frame: f.Frame, Nosplit: f.Has("nosplit")}
+	for _, in := range f.Calls() {
+		if strings.HasPrefix(in.Target(), "runtime.morestack") {
+			fr.Morestack = true
+			break
+		}
+	}
+	return fr
+}
@@ -0,0 +1,112 @@
+package directive
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+func TestTag(t *testing.T) {
+	if tag, err := Tag("nosplit"); err != nil || tag != "directive_nosplit" {
+		t.Errorf("Tag(nosplit) = %q, %v", tag, err)
+	}
+	if _, err := Tag("inline"); err == nil {
+		t.Error("Tag(inline) did not fail")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	morestack := asm.Instr{Op: "CALL", Args: "runtime.morestack_noctxt(SB)"}
+	call := asm.Instr{Op: "CALL", Args: "p.f[go.shape.[64]uint8](SB)"}
+	listings := map[string][]*asm.Func{
+		"noinline": {
+			{Name: "p.f[go.shape.[64]uint8]", Flags: []string{"nosplit"}},
+			{Name: "p.g", Frame: 80, Instrs: []asm.Instr{call, morestack}},
+			{Name: "p.h", Frame: 8},
+		},
+		"default": {
+			{Name: "p.g", Frame: 16},
+		},
+	}
+	marked := map[string]bool{"p.f": true, "p.h": true}
+	callees := Compare(listings, "p.", marked, regexp.MustCompile(`^f`))
+	if len(callees) != 1 {
+		t.Fatalf("got %d callees, want 1", len(callees))
+	}
+	c := callees[0]
+	if c.Name != "f[go.shape.[64]uint8]" || c.Calls["noinline"] != 1 || c.Inlined("noinline") || !c.Inlined("default") {
+		t.Errorf("callee = %+v", c)
+	}
+	if f := c.Modes["noinline"]; !f.Compiled || !f.Nosplit || f.Morestack {
+		t.Errorf("noinline callee = %+v", f)
+	}
+	if f := c.Modes["default"]; f.Compiled {
+		t.Errorf("default callee = %+v, want not compiled", f)
+	}
+	if len(c.Callers) != 1 || c.Callers[0].Name != "g" {
+		t.Fatalf("callers = %+v, want g", c.Callers)
+	}
+	g := c.Callers[0].Modes
+	if g["noinline"] != (Frame{Compiled: true, Frame: 80, Morestack: true}) || g["default"] != (Frame{Compiled: true, Frame: 16}) {
+		t.Errorf("caller = %+v", g)
+	}
+}
+
+func TestNosplitLegal(t *testing.T) {
+	// On amd64 the frame and two 8 byte return addresses must fit in
+	// NosplitLimit, which leaves 784 bytes for the frame.
+	if !NosplitLegal(Frame{Frame: NosplitLimit - 16}, "amd64") || NosplitLegal(Frame{Frame: NosplitLimit - 15}, "amd64") {
+		t.Errorf("NosplitLegal does not allow frames up to NosplitLimit-16 = %d bytes on amd64", NosplitLimit-16)
+	}
+}
+
+func TestScenarioCallees(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles the scenario package three times")
+	}
+	pkg, err := load.Dir("../scenario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listings, err := Compile(pkg.Dir, Modes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callees := Compare(listings, pkg.SymbolPrefix(), Marked(pkg), regexp.MustCompile(`^(byValue|runValue)\[go\.shape\.\[262144\]`))
+	found := 0
+	for _, c := range callees {
+		if len(c.Callers) != 1 {
+			t.Errorf("%s: callers = %+v, want one", c.Name, c.Callers)
+			continue
+		}
+		caller := c.Callers[0].Modes
+		// The copy of the argument makes the caller's frame, and only
+		// inlining takes it out.
+		if f := caller["noinline"]; f.Frame < 256<<10 || !f.Morestack {
+			t.Errorf("%s: noinline caller = %+v, want the copy in a checked frame", c.Name, f)
+		}
+		if f := caller["default"]; !c.Inlined("default") || f.Frame >= 1<<10 {
+			t.Errorf("%s: default caller = %+v, inlined %v; want the call and the copy gone", c.Name, f, c.Inlined("default"))
+		}
+		if f := caller["nosplit"]; f != caller["noinline"] {
+			t.Errorf("%s: nosplit caller = %+v, want as with noinline", c.Name, f)
+		}
+		if f := c.Modes["nosplit"]; !f.Nosplit || f.Morestack {
+			t.Errorf("%s: nosplit callee = %+v", c.Name, f)
+		}
+		if strings.HasPrefix(c.Name, "runValue") {
+			// Calling wg.Done, runValue is not a leaf and keeps its
+			// stack check unless marked nosplit.
+			if f := c.Modes["noinline"]; f.Nosplit || !f.Morestack {
+				t.Errorf("%s: noinline callee = %+v, want the stack check", c.Name, f)
+			}
+		}
+		found++
+	}
+	if found != 2 {
+		t.Errorf("found %d of the callees byValue and runValue", found)
+	}
+}
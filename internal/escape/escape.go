@@ -0,0 +1,116 @@
+// Package escape runs the compiler's escape analysis on a package and
+// decodes its diagnostics ("-gcflags=-m").
+package escape
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind classifies a diagnostic.
+type Kind int
+
+const (
+	Other        Kind = iota
+	MovedToHeap       // "moved to heap: x"
+	Escapes           // "new(T) escapes to heap"
+	NoEscape          // "x does not escape"
+	LeakingParam      // "leaking param: p", "leaking param content: p"
+	ZeroCopy          // "zero-copy string->[]byte conversion"
+)
+
+var kindNames = [...]string{"other", "moved to heap", "escapes", "does not escape", "leaking param", "zero-copy conversion"}
+
+func (k Kind) String() string { return kindNames[k] }
+
+// Diag is one escape analysis diagnostic.
+type Diag struct {
+	File    string // absolute path
+	Line    int
+	Col     int
+	Kind    Kind
+	Subject string // the variable, parameter or expression
+	Message string // the diagnostic as printed by the compiler
+}
+
+func (d Diag) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Col, d.Message)
+}
+
+// Run builds the package in dir with -gcflags=-m and returns the escape
+// diagnostics for it. Extra compiler flags, such as "-l", may be added.
+func Run(dir string, gcflags ...string) ([]Diag, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	flags := append([]string{"-m"}, gcflags...)
+	cmd := exec.Command("go", "build", "-gcflags="+strings.Join(flags, " "), "-o", os.DevNull, ".")
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go build: %v\n%s", err, out.Bytes())
+	}
+	return Parse(&out, dir)
+}
+
+var diagRE = regexp.MustCompile(`^(.+?\.go):(\d+):(\d+): (.*)$`)
+
+// Parse decodes compiler output. Relative file names are resolved against
+// dir; lines other than escape diagnostics are skipped.
+func Parse(r io.Reader, dir string) ([]Diag, error) {
+	var diags []Diag
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		m := diagRE.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		d := Diag{File: m[1], Message: m[4]}
+		if !filepath.IsAbs(d.File) {
+			d.File = filepath.Join(dir, d.File)
+		}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Col, _ = strconv.Atoi(m[3])
+		if !classify(&d) {
+			continue
+		}
+		diags = append(diags, d)
+	}
+	return diags, sc.Err()
+}
+
+func classify(d *Diag) bool {
+	msg := d.Message
+	switch {
+	case strings.HasPrefix(msg, "moved to heap: "):
+		d.Kind, d.Subject = MovedToHeap, strings.TrimPrefix(msg, "moved to heap: ")
+	case strings.HasPrefix(msg, "leaking param content: "):
+		d.Kind, d.Subject = LeakingParam, strings.TrimPrefix(msg, "leaking param content: ")
+	case strings.HasPrefix(msg, "leaking param: "):
+		d.Kind, d.Subject = LeakingParam, strings.TrimPrefix(msg, "leaking param: ")
+		if i := strings.Index(d.Subject, " "); i >= 0 {
+			d.Subject = d.Subject[:i]
+		}
+	case strings.HasPrefix(msg, "zero-copy "):
+		d.Kind = ZeroCopy
+	case strings.HasSuffix(msg, " escapes to heap"):
+		d.Kind, d.Subject = Escapes, strings.TrimSuffix(msg, " escapes to heap")
+	case strings.HasSuffix(msg, " does not escape"):
+		d.Kind, d.Subject = NoEscape, strings.TrimSuffix(msg, " does not escape")
+	default:
+		return false
+	}
+	return true
+}
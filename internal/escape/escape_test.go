@@ -0,0 +1,65 @@
+package escape
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const out = `# example.com/p
+./p.go:3:6: can inline f
+./p.go:5:7: leaking param: p
+./p.go:6:7: leaking param: q to result ~r0 level=0
+./p.go:7:7: leaking param content: s
+./p.go:9:2: moved to heap: x
+./p.go:10:9: new(T) escapes to heap
+./p.go:11:9: &T{...} does not escape
+./p.go:12:14: zero-copy string->[]byte conversion
+/abs/q.go:1:1: x escapes to heap
+`
+	diags, err := Parse(strings.NewReader(out), "/src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		file    string
+		line    int
+		kind    Kind
+		subject string
+	}{
+		{"/src/p.go", 5, LeakingParam, "p"},
+		{"/src/p.go", 6, LeakingParam, "q"},
+		{"/src/p.go", 7, LeakingParam, "s"},
+		{"/src/p.go", 9, MovedToHeap, "x"},
+		{"/src/p.go", 10, Escapes, "new(T)"},
+		{"/src/p.go", 11, NoEscape, "&T{...}"},
+		{"/src/p.go", 12, ZeroCopy, ""},
+		{"/abs/q.go", 1, Escapes, "x"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %v", len(diags), len(want), diags)
+	}
+	for i, w := range want {
+		d := diags[i]
+		if d.File != filepath.FromSlash(w.file) || d.Line != w.line || d.Kind != w.kind || d.Subject != w.subject {
+			t.Errorf("diag %d = %s:%d %v %q, want %s:%d %v %q", i, d.File, d.Line, d.Kind, d.Subject, w.file, w.line, w.kind, w.subject)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	diags, err := Run("../..", "-l")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, d := range diags {
+		if filepath.Base(d.File) == "main.go" && d.Kind == NoEscape && d.Subject == "obj" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no \"obj does not escape\" diagnostic for main.go in %v", diags)
+	}
+}
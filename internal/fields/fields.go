@@ -0,0 +1,336 @@
+// Package fields finds which fields of large structs functions actually
+// touch. For every function taking a struct above the size threshold by
+// value or by pointer it lists the fields read and written through that
+// parameter, and across the package it ranks the fields of each such type
+// by how many functions use them, to suggest splitting rarely used bulk
+// away from the fields every caller needs.
+package fields
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/layout"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// HotShare is the share of the functions using a type that must touch a
+// field for it to count as hot.
+const HotShare = 0.5
+
+// Func is the field usage of one large struct parameter of a function.
+type Func struct {
+	Func    string         `json:"func"`
+	Pos     token.Position `json:"pos"`
+	Param   string         `json:"param"`
+	Type    string         `json:"type"`
+	Pointer bool           `json:"pointer"`
+	Size    int64          `json:"size"`
+	Reads   []string       `json:"reads"`
+	Writes  []string       `json:"writes"` // including fields whose address is taken
+	// Whole lists the uses of the parameter other than field selections:
+	// copies, calls passing it on, method calls. Fields used through them
+	// are not known.
+	Whole     []Use `json:"whole,omitempty"`
+	UsedBytes int64 `json:"used_bytes"`
+
+	st *types.Struct
+}
+
+// Use is a use of a parameter as a whole.
+type Use struct {
+	Pos  token.Position `json:"pos"`
+	What string         `json:"what"`
+}
+
+// Type is the usage of the fields of one struct type across the package.
+type Type struct {
+	Type  string  `json:"type"`
+	Size  int64   `json:"size"`
+	Funcs int     `json:"funcs"` // functions that use it only through fields
+	Whole int     `json:"whole"` // functions that also use it as a whole
+	Field []Field `json:"fields"`
+	// Hot and cold fields, and the suggestion to split the type when its
+	// cold fields are most of its size.
+	HotBytes  int64 `json:"hot_bytes"`
+	ColdBytes int64 `json:"cold_bytes"`
+	Split     bool  `json:"split"`
+}
+
+// Field is the usage of one field.
+type Field struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	Readers int    `json:"readers"`
+	Writers int    `json:"writers"`
+	Users   int    `json:"users"` // functions reading or writing it
+	Hot     bool   `json:"hot"`
+}
+
+// Report is the result for a package.
+type Report struct {
+	Funcs []*Func `json:"funcs"`
+	Types []*Type `json:"types"`
+}
+
+// Analyze finds the large struct parameters of the functions of pkg and
+// the fields used through them. Files generated by this module, such as the
+// split types written by cmd/split, are left out: their conversions touch
+// every field and would count as users of all of them.
+func Analyze(pkg *load.Package, cfg *config.Config) *Report {
+	r := &Report{}
+	for _, file := range pkg.Files {
+		if generated(pkg, file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			for _, list := range []*ast.FieldList{fn.Recv, fn.Type.Params} {
+				if list == nil {
+					continue
+				}
+				for _, field := range list.List {
+					for _, name := range field.Names {
+						if f := analyzeParam(pkg, cfg, fn, name); f != nil {
+							r.Funcs = append(r.Funcs, f)
+						}
+					}
+				}
+			}
+		}
+	}
+	r.Types = aggregate(pkg, r.Funcs)
+	return r
+}
+
+// generated reports whether file was generated by internal/layout.
+func generated(pkg *load.Package, file *ast.File) bool {
+	src, err := os.ReadFile(pkg.Fset.File(file.Pos()).Name())
+	return err == nil && layout.IsGenerated(src)
+}
+
+// largeStruct returns the struct behind t, a struct type or a pointer to
+// one, if it exceeds the threshold.
+func largeStruct(pkg *load.Package, cfg *config.Config, t types.Type) (*types.Struct, types.Type, bool, int64) {
+	pointer := false
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t, pointer = p.Elem(), true
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, false, 0
+	}
+	size, ok := pkg.Sizeof(t)
+	if !ok {
+		return nil, nil, false, 0
+	}
+	if exceeds, _ := cfg.Exceeds(pkg.Path, load.TypeName(t), size); !exceeds {
+		return nil, nil, false, 0
+	}
+	return st, t, pointer, size
+}
+
+func analyzeParam(pkg *load.Package, cfg *config.Config, fn *ast.FuncDecl, name *ast.Ident) *Func {
+	obj := pkg.Info.Defs[name]
+	if obj == nil || name.Name == "_" {
+		return nil
+	}
+	st, t, pointer, size := largeStruct(pkg, cfg, obj.Type())
+	if st == nil {
+		return nil
+	}
+	f := &Func{
+		Func:    pkg.Symbol(fn),
+		Pos:     pkg.Fset.Position(name.Pos()),
+		Param:   name.Name,
+		Type:    load.TypeName(t),
+		Pointer: pointer,
+		Size:    size,
+		st:      st,
+	}
+	reads, writes := make(map[int]bool), make(map[int]bool)
+
+	var stack []ast.Node
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		id, ok := n.(*ast.Ident)
+		if !ok || pkg.Info.Uses[id] != obj {
+			return true
+		}
+		parents := stack[:len(stack)-1]
+		sel, ok := parent(parents, 0).(*ast.SelectorExpr)
+		if !ok || sel.X != id {
+			f.Whole = append(f.Whole, Use{pkg.Fset.Position(id.Pos()), describe(parents, id)})
+			return true
+		}
+		s := pkg.Info.Selections[sel]
+		if s == nil || s.Kind() != types.FieldVal {
+			f.Whole = append(f.Whole, Use{pkg.Fset.Position(id.Pos()), "method " + sel.Sel.Name + " called"})
+			return true
+		}
+		index := s.Index()[0]
+		if written(parents[:len(parents)-1], sel) {
+			writes[index] = true
+		} else {
+			reads[index] = true
+		}
+		return true
+	})
+
+	used := make(map[int]bool)
+	for i := range reads {
+		f.Reads = append(f.Reads, st.Field(i).Name())
+		used[i] = true
+	}
+	for i := range writes {
+		f.Writes = append(f.Writes, st.Field(i).Name())
+		used[i] = true
+	}
+	for i := range used {
+		if s, ok := pkg.Sizeof(st.Field(i).Type()); ok {
+			f.UsedBytes += s
+		}
+	}
+	sort.Strings(f.Reads)
+	sort.Strings(f.Writes)
+	return f
+}
+
+// parent returns the node i levels above the last of stack.
+func parent(stack []ast.Node, i int) ast.Node {
+	if len(stack) <= i {
+		return nil
+	}
+	return stack[len(stack)-1-i]
+}
+
+// written reports whether the field selection sel, or an element or field
+// of it, is assigned to, incremented or has its address taken. stack holds
+// the ancestors of sel.
+func written(stack []ast.Node, sel ast.Expr) bool {
+	expr := sel
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch p := stack[i].(type) {
+		case *ast.SelectorExpr:
+			if p.X != expr {
+				return false
+			}
+		case *ast.IndexExpr:
+			if p.X != expr {
+				return false
+			}
+		case *ast.ParenExpr:
+		case *ast.AssignStmt:
+			for _, lhs := range p.Lhs {
+				if lhs == expr {
+					return true
+				}
+			}
+			return false
+		case *ast.IncDecStmt:
+			return p.X == expr
+		case *ast.UnaryExpr:
+			return p.Op == token.AND && p.X == expr
+		case *ast.RangeStmt:
+			return p.Key == expr || p.Value == expr
+		default:
+			return false
+		}
+		expr = stack[i].(ast.Expr)
+	}
+	return false
+}
+
+// describe says how a parameter is used as a whole.
+func describe(stack []ast.Node, id *ast.Ident) string {
+	switch p := parent(stack, 0).(type) {
+	case *ast.CallExpr:
+		if p.Fun != id {
+			return "passed to " + types.ExprString(p.Fun)
+		}
+	case *ast.StarExpr:
+		return "dereferenced"
+	case *ast.UnaryExpr:
+		if p.Op == token.AND {
+			return "address taken"
+		}
+	case *ast.AssignStmt, *ast.ValueSpec:
+		return "assigned"
+	case *ast.ReturnStmt:
+		return "returned"
+	case *ast.CompositeLit, *ast.KeyValueExpr:
+		return "stored in a composite literal"
+	case *ast.SendStmt:
+		return "sent on a channel"
+	case *ast.BinaryExpr:
+		return "compared"
+	}
+	return "used"
+}
+
+func aggregate(pkg *load.Package, funcs []*Func) []*Type {
+	byType := make(map[string]*Type)
+	index := make(map[string]map[string]int)
+	var order []string
+	for _, f := range funcs {
+		t := byType[f.Type]
+		if t == nil {
+			t = &Type{Type: f.Type, Size: f.Size}
+			byType[f.Type] = t
+			index[f.Type] = make(map[string]int)
+			order = append(order, f.Type)
+			st := f.st
+			for i := range st.NumFields() {
+				size, _ := pkg.Sizeof(st.Field(i).Type())
+				index[f.Type][st.Field(i).Name()] = len(t.Field)
+				t.Field = append(t.Field, Field{Name: st.Field(i).Name(), Size: size})
+			}
+		}
+		if len(f.Whole) > 0 {
+			t.Whole++
+			continue
+		}
+		t.Funcs++
+		users := make(map[string]bool)
+		for _, name := range f.Reads {
+			t.Field[index[f.Type][name]].Readers++
+			users[name] = true
+		}
+		for _, name := range f.Writes {
+			t.Field[index[f.Type][name]].Writers++
+			users[name] = true
+		}
+		for name := range users {
+			t.Field[index[f.Type][name]].Users++
+		}
+	}
+
+	list := make([]*Type, 0, len(order))
+	for _, name := range order {
+		t := byType[name]
+		for i := range t.Field {
+			fl := &t.Field[i]
+			fl.Hot = t.Funcs > 0 && float64(fl.Users) >= HotShare*float64(t.Funcs)
+			if fl.Hot {
+				t.HotBytes += fl.Size
+			} else {
+				t.ColdBytes += fl.Size
+			}
+		}
+		t.Split = t.Funcs >= 2 && t.HotBytes > 0 && t.ColdBytes*2 >= t.Size
+		sort.SliceStable(t.Field, func(i, j int) bool { return t.Field[i].Users > t.Field[j].Users })
+		list = append(list, t)
+	}
+	return list
+}
@@ -0,0 +1,106 @@
+package fields
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/layout"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+func TestAnalyze(t *testing.T) {
+	pkg, err := load.Dir("testdata/server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Analyze(pkg, config.Default())
+
+	byFunc := make(map[string]*Func)
+	for _, f := range r.Funcs {
+		byFunc[strings.TrimPrefix(f.Func, pkg.SymbolPrefix())] = f
+	}
+	if len(byFunc) != 5 {
+		t.Fatalf("got %d functions, want 5: %v", len(byFunc), r.Funcs)
+	}
+	for _, tt := range []struct {
+		fn            string
+		reads, writes []string
+		whole         int
+	}{
+		{"(*Server).Handle", []string{"ID", "Name"}, []string{"Hits"}, 0},
+		{"(*Server).Reset", nil, []string{"Cache", "Hits"}, 0},
+		{"Describe", []string{"Hits", "ID", "Name"}, nil, 0},
+		{"Fill", nil, []string{"Log"}, 0},
+		{"Save", nil, nil, 1},
+	} {
+		f := byFunc[tt.fn]
+		if f == nil {
+			t.Errorf("%s not analyzed", tt.fn)
+			continue
+		}
+		if !reflect.DeepEqual(f.Reads, tt.reads) || !reflect.DeepEqual(f.Writes, tt.writes) || len(f.Whole) != tt.whole {
+			t.Errorf("%s: reads %v writes %v whole %v; want %v, %v, %d", tt.fn, f.Reads, f.Writes, f.Whole, tt.reads, tt.writes, tt.whole)
+		}
+	}
+	if f := byFunc["Save"]; f != nil && len(f.Whole) == 1 && f.Whole[0].What != "dereferenced" {
+		t.Errorf("Save uses s as %q", f.Whole[0].What)
+	}
+
+	if len(r.Types) != 1 {
+		t.Fatalf("got %d types, want 1", len(r.Types))
+	}
+	ty := r.Types[0]
+	if ty.Funcs != 4 || ty.Whole != 1 || !ty.Split {
+		t.Errorf("type = %+v", ty)
+	}
+	hot := make(map[string]bool)
+	for _, f := range ty.Field {
+		hot[f.Name] = f.Hot
+	}
+	want := map[string]bool{"ID": true, "Name": true, "Hits": true, "Cache": false, "Log": false}
+	if !reflect.DeepEqual(hot, want) {
+		t.Errorf("hot fields = %v, want %v", hot, want)
+	}
+}
+
+// TestAnalyzeGenerated checks that a split type generated next to Server
+// does not count as a user of its fields.
+func TestAnalyzeGenerated(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.ReadFile("testdata/server/server.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"go.mod": []byte("module example.com/server\n"), "server.go": src} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	split, err := layout.Split(pkg, "Server", []string{"ID", "Name", "Hits"}, "split -type Server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "server_split.go"), split, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if pkg, err = load.Dir(dir); err != nil {
+		t.Fatal(err)
+	}
+	r := Analyze(pkg, config.Default())
+	for _, f := range r.Funcs {
+		if f.Pos.Filename != filepath.Join(dir, "server.go") {
+			t.Errorf("%s in %s analyzed", f.Func, f.Pos.Filename)
+		}
+	}
+	if len(r.Funcs) != 5 || len(r.Types) != 1 || r.Types[0].Funcs != 4 {
+		t.Errorf("got %d functions and types %+v, want 5 functions and the 4 users of Server", len(r.Funcs), r.Types)
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import "fmt"
+
+type Server struct {
+	ID    int
+	Name  string
+	Hits  int
+	Cache [4096]byte
+	Log   [1024]byte
+}
+
+func (s *Server) Handle() {
+	s.Hits++
+	fmt.Println(s.ID, s.Name)
+}
+
+func (s *Server) Reset() {
+	s.Hits = 0
+	s.Cache[0] = 0
+}
+
+func Describe(s Server) string {
+	return fmt.Sprint(s.ID, s.Name, s.Hits)
+}
+
+func Fill(s *Server) {
+	p := &s.Log
+	p[0] = 1
+}
+
+func Save(s *Server) {
+	Describe(*s)
+}
+
+func small(n int) int { return n }
@@ -0,0 +1,331 @@
+// Package heapattr attributes the allocations of a heap profile to the
+// escape analysis sites of a package, and singles out the values that are
+// on the heap because their address is passed to a pointer parameter.
+package heapattr
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/escape"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/profile"
+)
+
+// Causes of a heap allocation.
+const (
+	CausePointerParam = "pointer param"
+	CauseOther        = "other"
+)
+
+// Report is the result of Analyze.
+type Report struct {
+	AllocBytes   int64 `json:"alloc_bytes"`   // in the whole profile
+	AllocObjects int64 `json:"alloc_objects"` // in the whole profile
+
+	// Allocated by the escape sites of the package, split by cause.
+	SiteBytes           int64 `json:"site_bytes"`
+	SiteObjects         int64 `json:"site_objects"`
+	PointerParamBytes   int64 `json:"pointer_param_bytes"`
+	PointerParamObjects int64 `json:"pointer_param_objects"`
+
+	// Allocated by functions of the package at lines without an escape
+	// diagnostic, usually because the profile came from a different build.
+	UnmatchedBytes int64 `json:"unmatched_bytes"`
+
+	Sites []*Site `json:"sites"`
+}
+
+// Site is an escape analysis diagnostic that allocates.
+type Site struct {
+	Pos      token.Position `json:"pos"`
+	Function string         `json:"function"`
+	Message  string         `json:"message"`
+	Cause    string         `json:"cause"`
+	Via      []string       `json:"via,omitempty"` // the calls the address flows into
+	Bytes    int64          `json:"bytes"`
+	Objects  int64          `json:"objects"`
+}
+
+// Analyze matches the alloc_space and alloc_objects samples of a heap
+// profile with the "moved to heap" and "escapes to heap" diagnostics of
+// pkg. A sample belongs to the first frame above the runtime allocator.
+func Analyze(p *profile.Profile, pkg *load.Package, diags []escape.Diag) (*Report, error) {
+	space, objects := p.Index("alloc_space"), p.Index("alloc_objects")
+	if space < 0 || objects < 0 {
+		return nil, fmt.Errorf("not a heap profile: no alloc_space and alloc_objects samples")
+	}
+
+	type key struct {
+		file string
+		line int
+	}
+	sites := make(map[key]*Site)
+	leaks := make(map[key]bool)
+	for _, d := range diags {
+		if d.Kind == escape.LeakingParam {
+			leaks[key{d.File, d.Line}] = true
+		}
+	}
+	r := new(Report)
+	for _, d := range diags {
+		if d.Kind != escape.MovedToHeap && d.Kind != escape.Escapes {
+			continue
+		}
+		k := key{d.File, d.Line}
+		if old := sites[k]; old != nil && old.Cause == CausePointerParam {
+			continue
+		}
+		decl := enclosing(pkg, d.File, d.Line)
+		if decl == nil {
+			continue
+		}
+		s := &Site{
+			Pos:      token.Position{Filename: d.File, Line: d.Line, Column: d.Col},
+			Function: pkg.Symbol(decl),
+			Message:  d.Message,
+			Cause:    CauseOther,
+		}
+		for _, via := range flows(pkg, decl, d) {
+			s.Cause = CausePointerParam
+			if leaks[key{via.param.Filename, via.param.Line}] {
+				s.Via = append(s.Via, via.desc+" (leaking param)")
+			} else {
+				s.Via = append(s.Via, via.desc)
+			}
+		}
+		// A line can have several diagnostics, e.g. an argument escaping
+		// into an inlined call that appends. Keep the one explained by a
+		// pointer parameter.
+		if old := sites[k]; old != nil {
+			if s.Cause == CauseOther {
+				continue
+			}
+			*old = *s
+			continue
+		}
+		sites[k] = s
+		r.Sites = append(r.Sites, s)
+	}
+
+	funcs := pkg.Funcs()
+	for _, smp := range p.Sample {
+		b, n := smp.Value[space], smp.Value[objects]
+		r.AllocBytes += b
+		r.AllocObjects += n
+		frame, ok := allocator(smp.Stack())
+		if !ok {
+			continue
+		}
+		decl := funcs[load.BaseSymbol(frame.Function)]
+		if decl == nil {
+			continue
+		}
+		file := pkg.Fset.Position(decl.Pos()).Filename
+		s := sites[key{file, int(frame.Line)}]
+		if s == nil {
+			r.UnmatchedBytes += b
+			continue
+		}
+		s.Bytes += b
+		s.Objects += n
+		r.SiteBytes += b
+		r.SiteObjects += n
+		if s.Cause == CausePointerParam {
+			r.PointerParamBytes += b
+			r.PointerParamObjects += n
+		}
+	}
+	sort.SliceStable(r.Sites, func(i, j int) bool {
+		a, b := r.Sites[i], r.Sites[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		if a.Pos.Filename != b.Pos.Filename {
+			return a.Pos.Filename < b.Pos.Filename
+		}
+		return a.Pos.Line < b.Pos.Line
+	})
+	return r, nil
+}
+
+// allocator returns the first frame of the stack outside the runtime.
+func allocator(stack []profile.Frame) (profile.Frame, bool) {
+	for _, f := range stack {
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			return f, true
+		}
+	}
+	return profile.Frame{}, false
+}
+
+// enclosing returns the function declaration of pkg containing the line.
+func enclosing(pkg *load.Package, file string, line int) *ast.FuncDecl {
+	for _, f := range pkg.Files {
+		if pkg.Fset.Position(f.Pos()).Filename != file {
+			continue
+		}
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if pkg.Fset.Position(fn.Pos()).Line <= line && line <= pkg.Fset.Position(fn.End()).Line {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+type flow struct {
+	desc  string         // e.g. "Save(&r)" or "r.Keep()"
+	param token.Position // the parameter the address is passed to
+}
+
+// flows returns the calls that receive the address of the value allocated at
+// the diagnostic: as an argument, or as the implicit receiver of a method
+// with a pointer receiver.
+func flows(pkg *load.Package, decl *ast.FuncDecl, d escape.Diag) []flow {
+	var (
+		out     []flow
+		stack   []ast.Node
+		target  types.Object // the variable moved to heap
+		escaped ast.Expr     // the expression escaping
+	)
+	at := func(pos token.Pos) bool {
+		p := pkg.Fset.Position(pos)
+		return p.Line == d.Line && p.Column == d.Col
+	}
+
+	ast.Inspect(decl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if d.Kind == escape.MovedToHeap && target == nil && n.Name == d.Subject && at(n.Pos()) {
+				target = pkg.Info.Defs[n]
+			}
+		case *ast.UnaryExpr:
+			if d.Kind == escape.Escapes && escaped == nil && at(n.Pos()) {
+				escaped = n
+			}
+		case *ast.CompositeLit:
+			// The compiler positions a literal without & at its brace.
+			if d.Kind == escape.Escapes && escaped == nil && (at(n.Pos()) || at(n.Lbrace)) {
+				escaped = n
+			}
+		case *ast.CallExpr:
+			// The compiler positions calls at the opening parenthesis.
+			if d.Kind == escape.Escapes && escaped == nil && (at(n.Lparen) || at(n.Pos())) {
+				escaped = n
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		stack = append(stack, n)
+		var parent ast.Node
+		if len(stack) > 1 {
+			parent = stack[len(stack)-2]
+		}
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if target != nil && n.Op == token.AND && root(pkg, n.X) == target {
+				out = append(out, argFlow(pkg, parent, n)...)
+			}
+		case *ast.SelectorExpr:
+			if target != nil && root(pkg, n.X) == target {
+				if sel := pkg.Info.Selections[n]; sel != nil && sel.Kind() == types.MethodVal {
+					sig := sel.Obj().Type().(*types.Signature)
+					_, ptrRecv := sig.Recv().Type().(*types.Pointer)
+					_, ptrX := pkg.Info.TypeOf(n.X).Underlying().(*types.Pointer)
+					if ptrRecv && !ptrX {
+						out = append(out, flow{
+							desc:  types.ExprString(n) + "()",
+							param: pkg.Fset.Position(sig.Recv().Pos()),
+						})
+					}
+				}
+			}
+		}
+		if escaped != nil && n == escaped {
+			out = append(out, argFlow(pkg, parent, escaped)...)
+		}
+		return true
+	})
+	return out
+}
+
+// argFlow returns the flow of arg into the call parent, if it is one of
+// its arguments and the parameter it is passed to is a pointer. A value
+// passed to an interface parameter, such as the ...any of fmt.Println, is
+// boxed rather than passed by address.
+func argFlow(pkg *load.Package, parent ast.Node, arg ast.Expr) []flow {
+	call, ok := parent.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	t := pkg.Info.TypeOf(call.Fun)
+	if t == nil {
+		return nil
+	}
+	sig, ok := t.Underlying().(*types.Signature)
+	if !ok {
+		return nil
+	}
+	params := sig.Params()
+	for i, a := range call.Args {
+		if a != arg {
+			continue
+		}
+		if i >= params.Len() {
+			i = params.Len() - 1
+		}
+		param := params.At(i)
+		typ := param.Type()
+		if sig.Variadic() && i == params.Len()-1 && !call.Ellipsis.IsValid() {
+			typ = typ.(*types.Slice).Elem()
+		}
+		if _, ok := typ.Underlying().(*types.Pointer); !ok {
+			return nil
+		}
+		return []flow{{
+			desc:  types.ExprString(call),
+			param: pkg.Fset.Position(param.Pos()),
+		}}
+	}
+	return nil
+}
+
+// root returns the variable at the root of a selector, index or paren
+// expression such as x.a[2].b.
+func root(pkg *load.Package, e ast.Expr) types.Object {
+	for {
+		switch x := e.(type) {
+		case *ast.Ident:
+			return pkg.Info.Uses[x]
+		case *ast.SelectorExpr:
+			if _, ok := pkg.Info.TypeOf(x.X).Underlying().(*types.Pointer); ok {
+				return nil
+			}
+			e = x.X
+		case *ast.IndexExpr:
+			if _, ok := pkg.Info.TypeOf(x.X).Underlying().(*types.Array); !ok {
+				return nil
+			}
+			e = x.X
+		case *ast.ParenExpr:
+			e = x.X
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package heapattr
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/escape"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/profile"
+)
+
+const store = "github.com/rohanchauhan02/valuevspointer/internal/heapattr/testdata/store."
+
+func TestAnalyze(t *testing.T) {
+	dir := filepath.Join("testdata", "store")
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags, err := escape.Run(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &profile.Profile{SampleType: []profile.ValueType{
+		{Type: "alloc_objects", Unit: "count"},
+		{Type: "alloc_space", Unit: "bytes"},
+	}}
+	add := func(fn string, line int64, objects, bytes int64) {
+		stack := []string{"runtime.mallocgc", "runtime.newobject", fn}
+		s := &profile.Sample{Value: []int64{objects, bytes}}
+		for i, name := range stack {
+			l := profile.Line{Function: &profile.Function{Name: name}}
+			if i == len(stack)-1 {
+				l.Line = line
+			}
+			s.Location = append(s.Location, &profile.Location{Line: []profile.Line{l}})
+		}
+		p.Sample = append(p.Sample, s)
+	}
+	add(store+"ByPointer", 19, 10, 5760)
+	add(store+"NewArg", 29, 4, 2304)
+	add(store+"Global", 33, 1, 576)
+	add(store+"Method", 37, 2, 1152)
+	add(store+"NoEscape", 24, 1, 576)
+	add(store+"Boxed", 46, 1, 576)
+	add(store+"Boxed", 47, 1, 576)
+	add("main.main", 3, 1, 64)
+
+	r, err := Analyze(p, pkg, diags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AllocBytes != 11584 || r.SiteBytes != 10944 || r.UnmatchedBytes != 576 {
+		t.Errorf("alloc, site, unmatched bytes = %d, %d, %d; want 11584, 10944, 576", r.AllocBytes, r.SiteBytes, r.UnmatchedBytes)
+	}
+	if r.PointerParamBytes != 9216 || r.PointerParamObjects != 16 {
+		t.Errorf("pointer param = %d bytes, %d objects; want 9216, 16", r.PointerParamBytes, r.PointerParamObjects)
+	}
+
+	byLine := make(map[int]*Site)
+	for _, s := range r.Sites {
+		byLine[s.Pos.Line] = s
+	}
+	tests := []struct {
+		line  int
+		cause string
+		via   string
+	}{
+		{19, CausePointerParam, "Save(&r) (leaking param)"},
+		{29, CausePointerParam, "Save(new(Record)) (leaking param)"},
+		{33, CauseOther, ""},
+		{37, CausePointerParam, "r.Keep() (leaking param)"},
+		{46, CauseOther, ""},
+		{47, CauseOther, ""},
+	}
+	for _, tt := range tests {
+		s := byLine[tt.line]
+		if s == nil {
+			t.Errorf("no site at line %d", tt.line)
+			continue
+		}
+		var via string
+		if len(s.Via) > 0 {
+			via = s.Via[0]
+		}
+		if s.Cause != tt.cause || via != tt.via {
+			t.Errorf("line %d: cause %q via %q, want %q via %q", tt.line, s.Cause, via, tt.cause, tt.via)
+		}
+	}
+	if r.Sites[0].Pos.Line != 19 {
+		t.Errorf("largest site at line %d, want 19", r.Sites[0].Pos.Line)
+	}
+}
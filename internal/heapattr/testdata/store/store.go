@@ -0,0 +1,48 @@
+package store
+
+type Record struct {
+	Buf [512]byte
+	ID  int
+}
+
+var saved []*Record
+
+var global *Record
+
+func Save(r *Record) { saved = append(saved, r) }
+
+func Peek(r *Record) int { return r.ID }
+
+func (r *Record) Keep() { saved = append(saved, r) }
+
+func ByPointer() {
+	var r Record
+	Save(&r)
+}
+
+func NoEscape() int {
+	var r Record
+	return Peek(&r)
+}
+
+func NewArg() {
+	Save(new(Record))
+}
+
+func Global() {
+	global = &Record{}
+}
+
+func Method() {
+	var r Record
+	r.Keep()
+}
+
+var logged []any
+
+func Log(v ...any) { logged = append(logged, v...) }
+
+func Boxed() {
+	Log(Record{})
+	Log(&Record{})
+}
@@ -0,0 +1,217 @@
+// Package hotpath combines a CPU profile with the package source to find the
+// functions that spend their time copying large values passed by value.
+package hotpath
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/profile"
+)
+
+// copyFuncs are the runtime routines the compiler emits to copy values,
+// and the write barrier helpers they call for values containing pointers.
+var copyFuncs = map[string]bool{
+	"runtime.memmove":                    true,
+	"runtime.duffcopy":                   true,
+	"runtime.typedmemmove":               true,
+	"runtime.wbMove":                     true,
+	"runtime.bulkBarrierPreWrite":        true,
+	"runtime.bulkBarrierPreWriteSrcOnly": true,
+}
+
+// Report is the result of Analyze.
+type Report struct {
+	SampleType profile.ValueType `json:"sample_type"`
+	Total      int64             `json:"total"`
+	Copy       int64             `json:"copy"` // attributed to the candidates
+	Candidates []*Candidate      `json:"candidates"`
+}
+
+// Candidate is a function of the package that copies values.
+type Candidate struct {
+	Function string         `json:"function"`
+	Pos      token.Position `json:"pos"`
+	Copy     int64          `json:"copy"`
+	Percent  float64        `json:"percent"`
+	Reasons  []Reason       `json:"reasons"`
+}
+
+// Reason is a by-value transfer in the candidate that exceeds the threshold
+// and explains some of its copying.
+type Reason struct {
+	Pos  token.Position `json:"pos"`
+	Kind string         `json:"kind"` // "param", "call" or "result"
+	Name string         `json:"name"`
+	Type string         `json:"type"`
+	Size int64          `json:"size"`
+}
+
+// Analyze attributes copying time to the functions of pkg. Samples whose
+// leaf frames are copy routines count for the first frame above them.
+// Because the compiler also copies inline (REP MOVS on amd64, load/store
+// pairs on arm64), flat samples on a line of a function that passes or
+// receives a large value there count as well. Value selects the sample
+// value; see profile.Profile.Index.
+func Analyze(p *profile.Profile, value int, pkg *load.Package, cfg *config.Config) *Report {
+	r := &Report{SampleType: p.SampleType[value]}
+	type key struct {
+		sym  string
+		line int64
+	}
+	routine := make(map[string]int64)
+	flat := make(map[key]int64)
+	for _, s := range p.Sample {
+		v := s.Value[value]
+		r.Total += v
+		stack := s.Stack()
+		if fn, ok := copier(stack); ok {
+			routine[load.BaseSymbol(fn)] += v
+		} else if len(stack) > 0 {
+			flat[key{load.BaseSymbol(stack[0].Function), stack[0].Line}] += v
+		}
+	}
+
+	for sym, decl := range pkg.Funcs() {
+		rs := reasons(pkg, cfg, decl)
+		v := routine[sym]
+		lines := make(map[int64]bool)
+		for _, rs := range rs {
+			if rs.Kind != "param" && !lines[int64(rs.Pos.Line)] {
+				lines[int64(rs.Pos.Line)] = true
+				v += flat[key{sym, int64(rs.Pos.Line)}]
+			}
+		}
+		if v == 0 {
+			continue
+		}
+		c := &Candidate{
+			Function: sym,
+			Pos:      pkg.Fset.Position(decl.Pos()),
+			Copy:     v,
+			Reasons:  rs,
+		}
+		if r.Total > 0 {
+			c.Percent = 100 * float64(v) / float64(r.Total)
+		}
+		r.Copy += v
+		r.Candidates = append(r.Candidates, c)
+	}
+	sort.Slice(r.Candidates, func(i, j int) bool {
+		a, b := r.Candidates[i], r.Candidates[j]
+		if a.Copy != b.Copy {
+			return a.Copy > b.Copy
+		}
+		return a.Function < b.Function
+	})
+	return r
+}
+
+// copier returns the function that called the copy routines at the leaf of
+// the stack, if the stack ends in one. Copies made by the runtime itself,
+// such as growslice, are not value transfers and are skipped.
+func copier(stack []profile.Frame) (string, bool) {
+	copying := false
+	for _, f := range stack {
+		if copyFuncs[f.Function] {
+			copying = true
+			continue
+		}
+		if !copying || strings.HasPrefix(f.Function, "runtime.") {
+			return "", false
+		}
+		return f.Function, true
+	}
+	return "", false
+}
+
+// reasons lists the large values decl receives by value, and the large
+// values it passes to or receives from the functions it calls.
+func reasons(pkg *load.Package, cfg *config.Config, decl *ast.FuncDecl) []Reason {
+	var rs []Reason
+	add := func(pos token.Pos, kind, name string, t types.Type) {
+		if _, ok := t.Underlying().(*types.Pointer); ok {
+			return
+		}
+		size, ok := pkg.Sizeof(t)
+		if !ok {
+			return
+		}
+		if ok, _ := cfg.Exceeds(pkg.Path, load.TypeName(t), size); !ok {
+			return
+		}
+		rs = append(rs, Reason{
+			Pos:  pkg.Fset.Position(pos),
+			Kind: kind,
+			Name: name,
+			Type: types.TypeString(t, types.RelativeTo(pkg.Types)),
+			Size: size,
+		})
+	}
+
+	if obj, ok := pkg.Info.Defs[decl.Name].(*types.Func); ok {
+		sig := obj.Type().(*types.Signature)
+		if recv := sig.Recv(); recv != nil {
+			add(recv.Pos(), "param", recv.Name(), recv.Type())
+		}
+		for i := range sig.Params().Len() {
+			v := sig.Params().At(i)
+			add(v.Pos(), "param", v.Name(), v.Type())
+		}
+	}
+	if decl.Body == nil {
+		return rs
+	}
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sig, ok := pkg.Info.TypeOf(call.Fun).(*types.Signature)
+		if !ok {
+			return true
+		}
+		name := calleeName(call.Fun)
+		for i := range sig.Params().Len() {
+			if sig.Variadic() && i == sig.Params().Len()-1 {
+				break
+			}
+			add(call.Pos(), "call", name, sig.Params().At(i).Type())
+		}
+		for i := range sig.Results().Len() {
+			add(call.Pos(), "result", name, sig.Results().At(i).Type())
+		}
+		return true
+	})
+	return rs
+}
+
+func calleeName(fun ast.Expr) string {
+	var b strings.Builder
+	var walk func(ast.Expr)
+	walk = func(e ast.Expr) {
+		switch e := e.(type) {
+		case *ast.Ident:
+			b.WriteString(e.Name)
+		case *ast.SelectorExpr:
+			walk(e.X)
+			b.WriteString(".")
+			b.WriteString(e.Sel.Name)
+		case *ast.IndexExpr:
+			walk(e.X)
+		case *ast.IndexListExpr:
+			walk(e.X)
+		case *ast.ParenExpr:
+			walk(e.X)
+		default:
+			b.WriteString("func")
+		}
+	}
+	walk(fun)
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package hotpath
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+	"github.com/rohanchauhan02/valuevspointer/internal/profile"
+)
+
+const svc = "github.com/rohanchauhan02/valuevspointer/internal/hotpath/testdata/svc."
+
+// fakeProfile builds a CPU profile with one sample per stack, each stack
+// given leaf first as "function" or "function:line".
+func fakeProfile(stacks map[int64][]string) *profile.Profile {
+	p := &profile.Profile{SampleType: []profile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}}}
+	funcs := make(map[string]*profile.Function)
+	for v, stack := range stacks {
+		s := &profile.Sample{Value: []int64{1, v}}
+		for _, frame := range stack {
+			name, line, _ := strings.Cut(frame, ":")
+			n, _ := strconv.Atoi(line)
+			f := funcs[name]
+			if f == nil {
+				f = &profile.Function{ID: uint64(len(funcs) + 1), Name: name}
+				funcs[name] = f
+			}
+			s.Location = append(s.Location, &profile.Location{Line: []profile.Line{{Function: f, Line: int64(n)}}})
+		}
+		p.Sample = append(p.Sample, s)
+	}
+	return p
+}
+
+func TestAnalyze(t *testing.T) {
+	pkg, err := load.Dir(filepath.Join("testdata", "svc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := fakeProfile(map[int64][]string{
+		700: {"runtime.memmove", svc + "Serve", "main.main"},
+		200: {"runtime.duffcopy", svc + "Load", svc + "Serve.func1"},
+		50:  {"runtime.memmove", "bytes.(*Buffer).Write", svc + "Serve"},
+		40:  {svc + "Handle", svc + "Serve"},
+		30:  {svc + "Serve:17", "main.main"},
+		20:  {svc + "Serve:15", "main.main"},
+		10:  {"runtime.memmove", "runtime.growslice", svc + "Serve"},
+	})
+	r := Analyze(p, 1, pkg, config.Default())
+	if r.Total != 1050 || r.Copy != 930 {
+		t.Errorf("Total, Copy = %d, %d; want 1050, 930", r.Total, r.Copy)
+	}
+	if len(r.Candidates) != 2 {
+		t.Fatalf("got %d candidates, want 2: %+v", len(r.Candidates), r.Candidates)
+	}
+	serve, load := r.Candidates[0], r.Candidates[1]
+	if serve.Function != svc+"Serve" || serve.Copy != 730 {
+		t.Errorf("first candidate = %s %d, want Serve 730", serve.Function, serve.Copy)
+	}
+	if len(serve.Reasons) != 1 || serve.Reasons[0].Kind != "call" || serve.Reasons[0].Name != "Handle" || serve.Reasons[0].Size != 4160 {
+		t.Errorf("Serve reasons = %+v, want the call to Handle with 4160 bytes", serve.Reasons)
+	}
+	if load.Function != svc+"Load" || len(load.Reasons) != 1 || load.Reasons[0].Kind != "result" {
+		t.Errorf("second candidate = %+v, want Load with the result of Build", load)
+	}
+}
@@ -0,0 +1,31 @@
+package svc
+
+type Request struct {
+	Header [64]byte
+	Body   [4096]byte
+}
+
+type Small struct{ ID int64 }
+
+func Handle(r Request) int { // the copy into the callee's frame happens in Serve
+	return int(r.Header[0])
+}
+
+func Serve(reqs []Request) int {
+	n := 0
+	for _, r := range reqs {
+		n += Handle(r)
+	}
+	return n
+}
+
+func Build() Request {
+	return Request{}
+}
+
+func Load() Request {
+	r := Build()
+	return r
+}
+
+func Cheap(s Small) int64 { return s.ID }
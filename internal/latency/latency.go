@@ -0,0 +1,152 @@
+// Package latency times the operations of a scenario in small batches, so
+// that the distribution of operation times, and not only their mean, can be
+// reported.
+package latency
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/stats"
+)
+
+// Result is the latency distribution of one scenario.
+type Result struct {
+	Name    string   `json:"name"`
+	Batch   int      `json:"batch"`   // operations per timed batch
+	Samples int      `json:"samples"` // timed batches
+	Mean    float64  `json:"mean_ns"`
+	Min     float64  `json:"min_ns"`
+	P50     float64  `json:"p50_ns"`
+	P90     float64  `json:"p90_ns"`
+	P99     float64  `json:"p99_ns"`
+	P999    float64  `json:"p999_ns"`
+	Max     float64  `json:"max_ns"`
+	Buckets []Bucket `json:"histogram"`
+}
+
+// Bucket is a histogram bucket of per operation times in [Lo, Hi) ns.
+type Bucket struct {
+	Lo    float64 `json:"lo_ns"`
+	Hi    float64 `json:"hi_ns"`
+	Count int     `json:"count"`
+}
+
+// Resolution estimates the resolution of time.Now as the smallest non-zero
+// difference between consecutive readings.
+func Resolution() time.Duration {
+	best := time.Duration(math.MaxInt64)
+	for range 1000 {
+		t0 := time.Now()
+		t1 := time.Now()
+		for t1 == t0 {
+			t1 = time.Now()
+		}
+		if d := t1.Sub(t0); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// minBatchTime is how many timer resolutions a batch must last for the
+// timer's granularity to stay below 1% of the measurement.
+const minBatchTime = 100
+
+// Measure times batches of op for about d and returns the distribution of
+// the time per operation. The batch size is the smallest power of two whose
+// batches last minBatchTime timer resolutions, so for fast operations each
+// sample is the mean of a batch and the tail is smoothed accordingly.
+func Measure(name string, op func(), d time.Duration) Result {
+	floor := minBatchTime * Resolution()
+	batch := 1
+	for {
+		start := time.Now()
+		for range batch {
+			op()
+		}
+		if time.Since(start) >= floor || batch >= 1<<30 {
+			break
+		}
+		batch *= 2
+	}
+
+	var samples []float64
+	deadline := time.Now().Add(d)
+	for len(samples) < 10 || time.Now().Before(deadline) {
+		start := time.Now()
+		for range batch {
+			op()
+		}
+		samples = append(samples, float64(time.Since(start))/float64(batch))
+	}
+	return Summarize(name, batch, samples)
+}
+
+// Summarize computes the percentiles and histogram of per operation times.
+func Summarize(name string, batch int, samples []float64) Result {
+	slices.Sort(samples)
+	r := Result{Name: name, Batch: batch, Samples: len(samples)}
+	if len(samples) == 0 {
+		return r
+	}
+	r.Mean = stats.Mean(samples)
+	r.Min, r.Max = samples[0], samples[len(samples)-1]
+	r.P50 = stats.Percentile(samples, 50)
+	r.P90 = stats.Percentile(samples, 90)
+	r.P99 = stats.Percentile(samples, 99)
+	r.P999 = stats.Percentile(samples, 99.9)
+	r.Buckets = histogram(samples)
+	return r
+}
+
+// histogram buckets sorted samples on a log scale with four buckets per
+// doubling, from the bucket of the minimum to the bucket of the maximum.
+func histogram(sorted []float64) []Bucket {
+	const perDoubling = 4
+	index := func(x float64) int {
+		return int(math.Floor(math.Log2(math.Max(x, 1e-3)) * perDoubling))
+	}
+	bound := func(i int) float64 {
+		return math.Exp2(float64(i) / perDoubling)
+	}
+	lo, hi := index(sorted[0]), index(sorted[len(sorted)-1])
+	buckets := make([]Bucket, hi-lo+1)
+	for i := range buckets {
+		buckets[i].Lo, buckets[i].Hi = bound(lo+i), bound(lo+i+1)
+	}
+	for _, x := range sorted {
+		buckets[index(x)-lo].Count++
+	}
+	return buckets
+}
+
+// WriteHistogram draws the histogram of r as text bars.
+func WriteHistogram(w io.Writer, r Result) {
+	const width = 50
+	most := 0
+	for _, b := range r.Buckets {
+		most = max(most, b.Count)
+	}
+	for _, b := range r.Buckets {
+		bar := 0
+		if most > 0 {
+			bar = (b.Count*width + most - 1) / most
+		}
+		fmt.Fprintf(w, "%12s - %-12s %8d %s\n", format(b.Lo), format(b.Hi), b.Count, strings.Repeat("#", bar))
+	}
+}
+
+func format(ns float64) string {
+	switch {
+	case ns >= 1e6:
+		return fmt.Sprintf("%.3gms", ns/1e6)
+	case ns >= 1e3:
+		return fmt.Sprintf("%.3gµs", ns/1e3)
+	}
+	return fmt.Sprintf("%.3gns", ns)
+}
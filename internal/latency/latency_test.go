@@ -0,0 +1,46 @@
+package latency
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	var samples []float64
+	for i := 1; i <= 1000; i++ {
+		samples = append(samples, float64(i))
+	}
+	r := Summarize("x", 4, samples)
+	if r.P50 != 500 || r.P90 != 900 || r.P99 != 990 || r.P999 < 999 || r.Min != 1 || r.Max != 1000 {
+		t.Errorf("percentiles = %+v", r)
+	}
+	total := 0
+	for i, b := range r.Buckets {
+		total += b.Count
+		if b.Lo >= b.Hi || (i > 0 && b.Lo != r.Buckets[i-1].Hi) {
+			t.Errorf("bucket %d = [%v, %v) does not continue the previous one", i, b.Lo, b.Hi)
+		}
+	}
+	if total != len(samples) {
+		t.Errorf("histogram holds %d samples, want %d", total, len(samples))
+	}
+
+	var buf bytes.Buffer
+	WriteHistogram(&buf, r)
+	if lines := strings.Count(buf.String(), "\n"); lines != len(r.Buckets) {
+		t.Errorf("histogram has %d lines, want %d", lines, len(r.Buckets))
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	n := 0
+	r := Measure("count", func() { n++ }, 10*time.Millisecond)
+	if r.Batch < 1 || r.Samples < 10 || r.P50 <= 0 || r.P50 > r.P999 {
+		t.Errorf("Measure = %+v", r)
+	}
+	if n < r.Batch*r.Samples {
+		t.Errorf("op ran %d times, want at least %d", n, r.Batch*r.Samples)
+	}
+}
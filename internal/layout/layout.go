@@ -0,0 +1,124 @@
+// Package layout generates alternative memory layouts for the struct types
+// of a package: a struct-of-arrays counterpart for sequences of records, and
+// a hot/cold split that keeps the rarely used fields behind a pointer.
+package layout
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// lookup returns the named, non-generic struct type name of pkg.
+func lookup(pkg *load.Package, name string) (*types.Named, *types.Struct, error) {
+	obj, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: no type %s", pkg.Path, name)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok || obj.IsAlias() {
+		return nil, nil, fmt.Errorf("%s.%s is an alias", pkg.Path, name)
+	}
+	if named.TypeParams().Len() > 0 {
+		return nil, nil, fmt.Errorf("%s.%s is generic", pkg.Path, name)
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s.%s is not a struct", pkg.Path, name)
+	}
+	return named, st, nil
+}
+
+// A file is a generated Go file of pkg under construction.
+type file struct {
+	pkg     *load.Package
+	imports map[string]string // path to name
+	body    bytes.Buffer
+}
+
+func newFile(pkg *load.Package) *file {
+	return &file{pkg: pkg, imports: make(map[string]string)}
+}
+
+func (f *file) printf(format string, args ...any) {
+	fmt.Fprintf(&f.body, format, args...)
+}
+
+// typ spells t as seen from pkg, importing the packages it refers to.
+func (f *file) typ(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == f.pkg.Types {
+			return ""
+		}
+		f.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+// source returns the formatted file, headed by the command that generated
+// it.
+func (f *file) source(command string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by %s; DO NOT EDIT.\n\npackage %s\n", strconv.Quote(command), f.pkg.Types.Name())
+	if len(f.imports) > 0 {
+		paths := make([]string, 0, len(f.imports))
+		for p := range f.imports {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		b.WriteString("\nimport (\n")
+		for _, p := range paths {
+			fmt.Fprintf(&b, "\t%q\n", p)
+		}
+		b.WriteString(")\n")
+	}
+	b.Write(f.body.Bytes())
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v\n%s", err, b.Bytes())
+	}
+	return src, nil
+}
+
+// IsGenerated reports whether src was written by this package.
+func IsGenerated(src []byte) bool {
+	return bytes.HasPrefix(src, []byte("// Code generated by ")) && bytes.Contains(src[:bytes.IndexByte(src, '\n')+1], []byte("; DO NOT EDIT."))
+}
+
+// Load loads the package in dir leaving out the files at paths outputs,
+// which are about to be replaced and may no longer compile against the types
+// they were generated from; the errors from uses of what they declared are
+// ignored. It refuses to replace a file that was not generated.
+func Load(dir string, outputs ...string) (*load.Package, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[string]bool)
+	for _, out := range outputs {
+		old, err := os.ReadFile(out)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil && !IsGenerated(old) {
+			return nil, errors.New(out + " exists and was not generated; refusing to overwrite it")
+		}
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return nil, err
+		}
+		skip[abs] = true
+	}
+	return load.Partial(dir, func(name string) bool {
+		return !skip[filepath.Join(absDir, name)]
+	})
+}
@@ -0,0 +1,109 @@
+package layout
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+func TestSoA(t *testing.T) {
+	pkg, err := load.Partial("testdata/records", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.Errors) != 0 {
+		t.Errorf("got type errors %v", pkg.Errors)
+	}
+	src, err := SoA(pkg, []string{"Event"}, "soa -type Event")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsGenerated(src) {
+		t.Errorf("generated file lacks the header:\n%s", src)
+	}
+	for _, want := range []string{
+		"\"time\"",
+		"When []time.Time",
+		"Meta []Meta",
+		"func (s *EventSoA) At(i int) Event",
+		"func (s *EventSoA) Set(i int, v *Event)",
+		"func (s *EventSoA) Append(list ...Event)",
+		"func (s *EventSoA) AoS() []Event",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated file lacks %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(string(src), "_ ") {
+		t.Errorf("generated file keeps the blank field:\n%s", src)
+	}
+
+	// The generated file must compile with the package it was made for.
+	compiles(t, map[string][]byte{"event_soa.go": src})
+
+	for _, name := range []string{"Clash", "Kind", "Missing"} {
+		if _, err := SoA(pkg, []string{name}, "soa"); err == nil {
+			t.Errorf("SoA(%s) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	pkg, err := load.Partial("testdata/records", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hot := []string{"ID", "Meta"}
+	src, err := Split(pkg, "Event", hot, "split -type Event")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type EventHot struct {\n\tID int\n\tMeta\n\tCold *EventCold\n}",
+		"type EventCold struct {\n\tWhen time.Time\n\tTags []string\n}",
+		"func SplitEvent(v *Event) EventHot",
+		"func (h *EventHot) Join() Event",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated file lacks %q:\n%s", want, src)
+		}
+	}
+	bench, err := SplitBenchmarks(pkg, "Event", hot, "split -type Event")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bench), "func BenchmarkEventSplit(b *testing.B)") {
+		t.Errorf("benchmarks lack BenchmarkEventSplit:\n%s", bench)
+	}
+	// Loaded as an ordinary file, since load skips tests.
+	compiles(t, map[string][]byte{"event_split.go": src, "event_bench.go": bench})
+
+	for _, hot := range [][]string{nil, {"ID", "Missing"}, {"When", "ID", "Tags", "Meta"}} {
+		if _, err := Split(pkg, "Event", hot, "split"); err == nil {
+			t.Errorf("Split(Event, %q) succeeded, want an error", hot)
+		}
+	}
+}
+
+// compiles type-checks the files of testdata/records together with files.
+func compiles(t *testing.T, files map[string][]byte) {
+	t.Helper()
+	dir := t.TempDir()
+	records, err := os.ReadFile("testdata/records/records.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["go.mod"] = []byte("module example.com/records\n")
+	files["records.go"] = records
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := load.Dir(dir); err != nil {
+		t.Errorf("generated files do not compile: %v", err)
+	}
+}
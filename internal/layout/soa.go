@@ -0,0 +1,89 @@
+package layout
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// soaMethods are the methods of the generated struct-of-arrays types, which
+// no field may be named after.
+var soaMethods = map[string]bool{"Len": true, "At": true, "Set": true, "Append": true, "AoS": true}
+
+// SoA returns a Go file declaring, for each named struct type of pkg, a
+// struct-of-arrays counterpart with an "SoA" suffix: one slice per field,
+// so a loop over one field reads only that field's memory. The counterpart
+// has methods to read, replace and append whole records. Blank fields are
+// left out. Command is recorded in the file's header.
+func SoA(pkg *load.Package, names []string, command string) ([]byte, error) {
+	f := newFile(pkg)
+	for _, name := range names {
+		if err := soa(f, name); err != nil {
+			return nil, err
+		}
+	}
+	return f.source(command)
+}
+
+func soa(f *file, name string) error {
+	_, st, err := lookup(f.pkg, name)
+	if err != nil {
+		return err
+	}
+	var fields []*types.Var
+	for i := range st.NumFields() {
+		v := st.Field(i)
+		if v.Name() == "_" {
+			continue
+		}
+		if soaMethods[v.Name()] {
+			return fmt.Errorf("%s.%s: field %s clashes with a method of %sSoA", f.pkg.Path, name, v.Name(), name)
+		}
+		fields = append(fields, v)
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("%s.%s has no fields", f.pkg.Path, name)
+	}
+	soa := name + "SoA"
+	first := fields[0].Name()
+
+	f.printf("\n// %s holds a sequence of %s records as one slice per field.\n", soa, name)
+	f.printf("type %s struct {\n", soa)
+	for _, v := range fields {
+		f.printf("\t%s []%s\n", v.Name(), f.typ(v.Type()))
+	}
+	f.printf("}\n")
+
+	f.printf("\n// Len returns the number of records.\n")
+	f.printf("func (s *%s) Len() int { return len(s.%s) }\n", soa, first)
+
+	f.printf("\n// At returns record i.\n")
+	f.printf("func (s *%s) At(i int) %s {\n\treturn %s{\n", soa, name, name)
+	for _, v := range fields {
+		f.printf("\t\t%s: s.%[1]s[i],\n", v.Name())
+	}
+	f.printf("\t}\n}\n")
+
+	f.printf("\n// Set replaces record i with *v.\n")
+	f.printf("func (s *%s) Set(i int, v *%s) {\n", soa, name)
+	for _, v := range fields {
+		f.printf("\ts.%s[i] = v.%[1]s\n", v.Name())
+	}
+	f.printf("}\n")
+
+	f.printf("\n// Append adds the records of list to the end.\n")
+	f.printf("func (s *%s) Append(list ...%s) {\n\tfor i := range list {\n\t\tv := &list[i]\n", soa, name)
+	for _, v := range fields {
+		f.printf("\t\ts.%s = append(s.%[1]s, v.%[1]s)\n", v.Name())
+	}
+	f.printf("\t}\n}\n")
+
+	f.printf("\n// AoS returns the records as a slice of %s.\n", name)
+	f.printf("func (s *%s) AoS() []%s {\n\tlist := make([]%s, s.Len())\n\tfor i := range list {\n", soa, name, name)
+	for _, v := range fields {
+		f.printf("\t\tlist[i].%s = s.%[1]s[i]\n", v.Name())
+	}
+	f.printf("\t}\n\treturn list\n}\n")
+	return nil
+}
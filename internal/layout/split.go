@@ -0,0 +1,157 @@
+package layout
+
+import (
+	"fmt"
+	"go/types"
+	"slices"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// A splitting is a struct type divided into hot and cold fields.
+type splitting struct {
+	name      string
+	hot, cold []*types.Var
+}
+
+func newSplitting(pkg *load.Package, name string, hot []string) (*splitting, error) {
+	_, st, err := lookup(pkg, name)
+	if err != nil {
+		return nil, err
+	}
+	s := &splitting{name: name}
+	known := make(map[string]bool)
+	for i := range st.NumFields() {
+		v := st.Field(i)
+		if v.Name() == "_" {
+			continue
+		}
+		known[v.Name()] = true
+		if slices.Contains(hot, v.Name()) {
+			s.hot = append(s.hot, v)
+		} else {
+			s.cold = append(s.cold, v)
+		}
+		if v.Name() == "Cold" {
+			return nil, fmt.Errorf("%s.%s: field Cold clashes with the pointer to the cold part", pkg.Path, name)
+		}
+	}
+	for _, h := range hot {
+		if !known[h] {
+			return nil, fmt.Errorf("%s.%s has no field %s", pkg.Path, name, h)
+		}
+	}
+	if len(s.hot) == 0 || len(s.cold) == 0 {
+		return nil, fmt.Errorf("%s.%s: splitting needs both hot and cold fields", pkg.Path, name)
+	}
+	return s, nil
+}
+
+// Split returns a Go file splitting the named struct type T of pkg into a
+// type THot holding the hot fields and a pointer, Cold, to a type TCold
+// holding the others. SplitT converts a T and THot.Join converts back.
+// Embedded fields stay embedded, so selectors of hot fields read the same
+// on T and THot. Command is recorded in the file's header.
+func Split(pkg *load.Package, name string, hot []string, command string) ([]byte, error) {
+	s, err := newSplitting(pkg, name, hot)
+	if err != nil {
+		return nil, err
+	}
+	f := newFile(pkg)
+	hotName, coldName := name+"Hot", name+"Cold"
+
+	f.printf("\n// %s holds the fields of %s used by most of its functions, and a\n", hotName, name)
+	f.printf("// pointer to the others.\n")
+	f.printf("type %s struct {\n", hotName)
+	declare(f, s.hot)
+	f.printf("\tCold *%s\n}\n", coldName)
+
+	f.printf("\n// %s holds the fields of %s that %s keeps behind a pointer.\n", coldName, name, hotName)
+	f.printf("type %s struct {\n", coldName)
+	declare(f, s.cold)
+	f.printf("}\n")
+
+	f.printf("\n// Split%s copies the hot fields of *v into the result and the others\n", name)
+	f.printf("// into a newly allocated %s.\n", coldName)
+	f.printf("func Split%s(v *%s) %s {\n\treturn %s{\n", name, name, hotName, hotName)
+	for _, v := range s.hot {
+		f.printf("\t\t%s: v.%[1]s,\n", v.Name())
+	}
+	f.printf("\t\tCold: &%s{\n", coldName)
+	for _, v := range s.cold {
+		f.printf("\t\t\t%s: v.%[1]s,\n", v.Name())
+	}
+	f.printf("\t\t},\n\t}\n}\n")
+
+	f.printf("\n// Join merges h and its cold part back into one %s. The cold fields are\n", name)
+	f.printf("// zero when h.Cold is nil.\n")
+	f.printf("func (h *%s) Join() %s {\n\tv := %s{\n", hotName, name, name)
+	for _, v := range s.hot {
+		f.printf("\t\t%s: h.%[1]s,\n", v.Name())
+	}
+	f.printf("\t}\n\tif c := h.Cold; c != nil {\n")
+	for _, v := range s.cold {
+		f.printf("\t\tv.%s = c.%[1]s\n", v.Name())
+	}
+	f.printf("\t}\n\treturn v\n}\n")
+	return f.source(command)
+}
+
+// SplitBenchmarks returns a Go test file benchmarking, for the splitting
+// Split generates, functions that read the hot fields of T and of THot,
+// each taking the record by value and by pointer.
+func SplitBenchmarks(pkg *load.Package, name string, hot []string, command string) ([]byte, error) {
+	s, err := newSplitting(pkg, name, hot)
+	if err != nil {
+		return nil, err
+	}
+	f := newFile(pkg)
+	f.imports["testing"] = "testing"
+	hotName := name + "Hot"
+	sink := func(v *types.Var) string { return "sink" + name + v.Name() }
+
+	f.printf("\nvar (\n")
+	for _, v := range s.hot {
+		f.printf("\t%s %s\n", sink(v), f.typ(v.Type()))
+	}
+	f.printf(")\n")
+	for _, fn := range []struct{ name, param string }{
+		{"read" + name + "Value", name},
+		{"read" + name + "Pointer", "*" + name},
+		{"read" + hotName + "Value", hotName},
+		{"read" + hotName + "Pointer", "*" + hotName},
+	} {
+		f.printf("\n//go:noinline\nfunc %s(v %s) {\n", fn.name, fn.param)
+		for _, v := range s.hot {
+			f.printf("\t%s = v.%s\n", sink(v), v.Name())
+		}
+		f.printf("}\n")
+	}
+
+	f.printf("\n// Benchmark%sSplit reads the hot fields of %s and of %s.\n", name, name, hotName)
+	f.printf("func Benchmark%sSplit(b *testing.B) {\n", name)
+	f.printf("\tvar v %s\n\th := Split%s(&v)\n", name, name)
+	for _, run := range []struct{ name, call string }{
+		{"value/original", "read" + name + "Value(v)"},
+		{"value/split", "read" + hotName + "Value(h)"},
+		{"pointer/original", "read" + name + "Pointer(&v)"},
+		{"pointer/split", "read" + hotName + "Pointer(&h)"},
+	} {
+		f.printf("\tb.Run(%q, func(b *testing.B) {\n", run.name)
+		f.printf("\t\tfor i := 0; i < b.N; i++ {\n\t\t\t%s\n\t\t}\n\t})\n", run.call)
+	}
+	f.printf("}\n")
+	return f.source(command)
+}
+
+// declare prints the field declarations of fields, keeping embedded fields
+// embedded.
+func declare(f *file, fields []*types.Var) {
+	for _, v := range fields {
+		if v.Embedded() {
+			f.printf("\t%s\n", f.typ(v.Type()))
+		} else {
+			f.printf("\t%s %s\n", v.Name(), f.typ(v.Type()))
+		}
+	}
+}
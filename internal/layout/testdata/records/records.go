@@ -0,0 +1,21 @@
+package records
+
+import "time"
+
+type Event struct {
+	When time.Time
+	ID   int
+	_    [4]byte
+	Tags []string
+	Meta
+}
+
+type Meta struct {
+	Source string
+}
+
+type Clash struct {
+	Len int
+}
+
+type Kind int
@@ -0,0 +1,327 @@
+// Package load parses and type-checks a single package directory for the
+// analyzers, using only the standard library.
+package load
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Package is a parsed and type-checked package.
+type Package struct {
+	Path  string
+	Dir   string
+	Fset  *token.FileSet
+	Files []*ast.File
+	Types *types.Package
+	Info  *types.Info
+	Sizes types.Sizes
+
+	// GoVersion is the go directive of the module, e.g. "1.22.1". The
+	// language version of each file, which build constraints may raise, is
+	// in Info.FileVersions.
+	GoVersion string
+
+	// Errors are the type errors tolerated by Partial.
+	Errors []error
+}
+
+// Dir loads the non-test Go files in dir that match the build constraints
+// of the host. Sizes follow GOARCH, or the host architecture when it is
+// unset.
+func Dir(dir string) (*Package, error) {
+	return load(dir, nil, false)
+}
+
+// Partial is like Dir but loads only the files for whose base name keep
+// reports true, e.g. to leave out generated files about to be replaced.
+// Type errors, such as uses of what the left out files declared, are
+// recorded in the package's Errors instead of failing the load.
+func Partial(dir string, keep func(name string) bool) (*Package, error) {
+	return load(dir, keep, true)
+}
+
+func load(dir string, keep func(name string) bool, tolerant bool) (*Package, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	path, goVersion, err := module(dir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") || keep != nil && !keep(filepath.Base(name)) {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, filepath.Base(name)); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	arch := os.Getenv("GOARCH")
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	sizes := types.SizesFor("gc", arch)
+	if sizes == nil {
+		return nil, fmt.Errorf("unknown GOARCH %q", arch)
+	}
+	info := &types.Info{
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+		FileVersions: make(map[*ast.File]string),
+	}
+	var typeErrors []error
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Sizes:    sizes,
+	}
+	if goVersion != "" {
+		conf.GoVersion = "go" + goVersion
+	}
+	if tolerant {
+		conf.Error = func(err error) { typeErrors = append(typeErrors, err) }
+	}
+	tpkg, err := conf.Check(path, fset, files, info)
+	if err != nil && !tolerant {
+		return nil, err
+	}
+	return &Package{
+		GoVersion: goVersion,
+		Errors:    typeErrors,
+		Path:      path,
+		Dir:       dir,
+		Fset:      fset,
+		Files:     files,
+		Types:     tpkg,
+		Info:      info,
+		Sizes:     sizes,
+	}, nil
+}
+
+// ImportPath derives the import path of dir from the nearest go.mod.
+func ImportPath(dir string) (string, error) {
+	path, _, err := module(dir)
+	return path, err
+}
+
+// module returns the import path of dir and the go directive of the nearest
+// go.mod, e.g. "1.22.1", or "" if it has none.
+func module(dir string) (path, goVersion string, err error) {
+	for d := dir; ; {
+		f, err := os.Open(filepath.Join(d, "go.mod"))
+		if err == nil {
+			mod, goVersion := readMod(f)
+			f.Close()
+			if mod == "" {
+				return "", "", fmt.Errorf("%s: no module directive", filepath.Join(d, "go.mod"))
+			}
+			rel, err := filepath.Rel(d, dir)
+			if err != nil {
+				return "", "", err
+			}
+			if rel == "." {
+				return mod, goVersion, nil
+			}
+			return mod + "/" + filepath.ToSlash(rel), goVersion, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", "", errors.New("go.mod not found for " + dir)
+		}
+		d = parent
+	}
+}
+
+func readMod(f *os.File) (mod, goVersion string) {
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && mod == "" {
+			mod = strings.Trim(strings.TrimSpace(rest), `"`)
+		} else if rest, ok := strings.CutPrefix(line, "go "); ok && goVersion == "" {
+			goVersion = strings.TrimSpace(rest)
+		}
+	}
+	return mod, goVersion
+}
+
+// Rel returns path relative to the working directory if it is inside it,
+// for printing, and path otherwise. A position such as "file.go:12:3"
+// works as well.
+func Rel(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if r, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(r, "..") {
+			return r
+		}
+	}
+	return path
+}
+
+// TypeName returns the fully qualified name of t as used for configuration
+// keys, e.g. "github.com/rohanchauhan02/valuevspointer.BigStruct".
+func TypeName(t types.Type) string {
+	return types.TypeString(t, nil)
+}
+
+// SymbolPrefix is the prefix of the linker symbols of the package's
+// functions: "main." for commands, the import path and a dot otherwise.
+func (p *Package) SymbolPrefix() string {
+	if p.Types.Name() == "main" {
+		return "main."
+	}
+	return p.Path + "."
+}
+
+// Symbol returns the linker symbol of a declared function or method, e.g.
+// "main.PassByValue" or "example.com/p.(*T).M", as found in profiles and in
+// compiler output.
+func (p *Package) Symbol(fn *ast.FuncDecl) string {
+	name := fn.Name.Name
+	if fn.Recv != nil && len(fn.Recv.List) == 1 {
+		t := fn.Recv.List[0].Type
+		ptr := false
+		if star, ok := t.(*ast.StarExpr); ok {
+			ptr, t = true, star.X
+		}
+		switch x := t.(type) {
+		case *ast.IndexExpr:
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		}
+		if id, ok := t.(*ast.Ident); ok {
+			if ptr {
+				name = "(*" + id.Name + ")." + name
+			} else {
+				name = id.Name + "." + name
+			}
+		}
+	}
+	return p.SymbolPrefix() + name
+}
+
+// Funcs maps the symbol of every function declared in the package to its
+// declaration.
+func (p *Package) Funcs() map[string]*ast.FuncDecl {
+	m := make(map[string]*ast.FuncDecl)
+	for _, f := range p.Files {
+		for _, d := range f.Decls {
+			if fn, ok := d.(*ast.FuncDecl); ok {
+				m[p.Symbol(fn)] = fn
+			}
+		}
+	}
+	return m
+}
+
+// BaseSymbol strips from a symbol what the compiler appends to the
+// enclosing declaration: closure suffixes such as ".func1", ".gowrap2" or
+// "-range1", and generic shapes such as "[...]".
+func BaseSymbol(sym string) string {
+	if i := strings.Index(sym, "["); i >= 0 {
+		// Shapes nest brackets, e.g. "F[go.shape.[64]uint8]".
+		depth := 0
+		for j := i; j < len(sym); j++ {
+			switch sym[j] {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			}
+			if depth == 0 {
+				sym = sym[:i] + sym[j+1:]
+				break
+			}
+		}
+	}
+	if i := strings.Index(sym, "-range"); i >= 0 {
+		sym = sym[:i]
+	}
+	for {
+		i := strings.LastIndex(sym, ".")
+		if i < 0 || !closureSuffix(sym[i+1:]) {
+			return sym
+		}
+		sym = sym[:i]
+	}
+}
+
+func closureSuffix(s string) bool {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap"} {
+		if rest, ok := strings.CutPrefix(s, prefix); ok && rest != "" && strings.Trim(rest, "0123456789") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Sizeof returns the size of t in bytes. It reports false for types whose
+// size depends on a type parameter.
+func (p *Package) Sizeof(t types.Type) (int64, bool) {
+	if hasTypeParam(t, make(map[types.Type]bool)) {
+		return 0, false
+	}
+	return p.Sizes.Sizeof(t), true
+}
+
+func hasTypeParam(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		args := t.TypeArgs()
+		for i := range args.Len() {
+			if hasTypeParam(args.At(i), seen) {
+				return true
+			}
+		}
+		return hasTypeParam(t.Underlying(), seen)
+	case *types.Array:
+		return hasTypeParam(t.Elem(), seen)
+	case *types.Struct:
+		for i := range t.NumFields() {
+			if hasTypeParam(t.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
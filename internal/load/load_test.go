@@ -0,0 +1,62 @@
+package load
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	p, err := Dir("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Path != "github.com/rohanchauhan02/valuevspointer" {
+		t.Errorf("Path = %q", p.Path)
+	}
+	big := p.Types.Scope().Lookup("BigStruct")
+	if big == nil {
+		t.Fatal("BigStruct not found")
+	}
+	if got := p.Sizes.Sizeof(big.Type()); got != 1<<18 {
+		t.Errorf("Sizeof(BigStruct) = %d, want %d", got, 1<<18)
+	}
+	if got := TypeName(big.Type()); got != "github.com/rohanchauhan02/valuevspointer.BigStruct" {
+		t.Errorf("TypeName = %q", got)
+	}
+	if _, ok := p.Funcs()["main.PassByValue"]; !ok {
+		t.Errorf("Funcs() lacks main.PassByValue: %v", p.Funcs())
+	}
+}
+
+func TestBaseSymbol(t *testing.T) {
+	tests := map[string]string{
+		"main.main":                       "main.main",
+		"main.main.func1":                 "main.main",
+		"main.main.func1.gowrap2":         "main.main",
+		"example.com/p.(*T).M.func3":      "example.com/p.(*T).M",
+		"example.com/p.Map[...]":          "example.com/p.Map",
+		"example.com/p.Map[go.shape.int]": "example.com/p.Map",
+		"p.F[go.shape.[64]uint8].func1":   "p.F",
+		"example.com/p.F-range1":          "example.com/p.F",
+		"example.com/p.funcs":             "example.com/p.funcs",
+	}
+	for in, want := range tests {
+		if got := BaseSymbol(in); got != want {
+			t.Errorf("BaseSymbol(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRel(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Rel(filepath.Join(wd, "testdata", "a.go") + ":3:1"); got != filepath.Join("testdata", "a.go")+":3:1" {
+		t.Errorf("Rel inside the working directory = %q", got)
+	}
+	if got := Rel(filepath.Dir(wd)); got != filepath.Dir(wd) {
+		t.Errorf("Rel outside the working directory = %q", got)
+	}
+}
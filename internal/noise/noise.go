@@ -0,0 +1,196 @@
+// Package noise runs a controlled background load next to the scenarios:
+// memory bandwidth hogs, CPU spinners and allocation churners, each on its
+// own locked thread. Where the host allows it, the load runs on CPUs of its
+// own and the rest of the process on the others.
+package noise
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/shard"
+)
+
+// Kinds lists the loads, with what they do.
+var Kinds = map[string]string{
+	"bandwidth": "copies between two 64MB buffers, larger than the caches",
+	"cpu":       "spins on arithmetic without touching memory",
+	"alloc":     "allocates short-lived objects of 16B to 32KB, keeping the garbage collector busy",
+}
+
+// bandwidthBuffer is the size of each buffer of a bandwidth worker.
+const bandwidthBuffer = 64 << 20
+
+// Spec is a kind of load and the number of workers running it.
+type Spec struct {
+	Kind    string
+	Workers int
+}
+
+func (s Spec) String() string {
+	return fmt.Sprintf("%s:%d", s.Kind, s.Workers)
+}
+
+// Parse parses a comma separated list of "kind" or "kind:workers".
+func Parse(list string) ([]Spec, error) {
+	var specs []Spec
+	for _, item := range strings.Split(list, ",") {
+		kind, n, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if _, known := Kinds[kind]; !known {
+			return nil, fmt.Errorf("unknown background load %q", kind)
+		}
+		workers := 1
+		if ok {
+			var err error
+			if workers, err = strconv.Atoi(n); err != nil || workers < 1 {
+				return nil, fmt.Errorf("invalid number of workers in %q", item)
+			}
+		}
+		specs = append(specs, Spec{kind, workers})
+	}
+	return specs, nil
+}
+
+// Load is a running background load.
+type Load struct {
+	start   time.Time
+	procs   int
+	allowed []int // the process's CPUs before Start, restored by Stop
+	stop    atomic.Bool
+	wg      sync.WaitGroup
+	work    map[string]*atomic.Int64 // bytes copied, iterations or bytes allocated
+	// CPUs are the CPUs the rest of the process, the scenarios included,
+	// is pinned to while the load runs, nil when it is not pinned.
+	CPUs []int
+	// Shared is set when the load could not be kept off the scenarios' CPUs.
+	Shared string
+}
+
+// Start starts the workers of specs and raises GOMAXPROCS by their number,
+// so the scenarios keep the processors they had. Where at least two CPUs
+// are allowed, the workers get the last of them, one each as long as one
+// is left over, and every thread of the process is first pinned to the
+// rest, which keeps the scenarios there, however the scheduler moves their
+// goroutines between threads, and the threads started later with them; the
+// workers then move their own threads to theirs. With fewer CPUs, or where
+// affinity cannot be set, the load runs unpinned and Shared says why.
+func Start(specs []Spec) *Load {
+	l := &Load{start: time.Now(), work: make(map[string]*atomic.Int64)}
+	var others []int
+	cpus, err := shard.Allowed()
+	switch {
+	case err != nil:
+		l.Shared = err.Error()
+	case len(cpus) < 2:
+		l.Shared = "only one CPU is available"
+	default:
+		keep := max(len(cpus)-workers(specs), 1)
+		if err := shard.Pin(cpus[:keep]...); err != nil {
+			l.Shared = err.Error()
+			break
+		}
+		l.allowed = cpus
+		l.CPUs = cpus[:keep]
+		others = cpus[keep:]
+	}
+	total := 0
+	for _, s := range specs {
+		if l.work[s.Kind] == nil {
+			l.work[s.Kind] = new(atomic.Int64)
+		}
+		for range s.Workers {
+			total++
+			l.wg.Add(1)
+			go l.worker(s.Kind, others)
+		}
+	}
+	l.procs = runtime.GOMAXPROCS(runtime.GOMAXPROCS(0) + total)
+	return l
+}
+
+func workers(specs []Spec) int {
+	n := 0
+	for _, s := range specs {
+		n += s.Workers
+	}
+	return n
+}
+
+func (l *Load) worker(kind string, cpus []int) {
+	defer l.wg.Done()
+	// The thread is never unlocked: pinned to the load's CPUs, it must not
+	// go back to the scheduler, and it exits with the goroutine.
+	runtime.LockOSThread()
+	if cpus != nil {
+		shard.PinThread(cpus...) // best effort; the load still runs on the scenarios' CPUs
+	}
+	done := l.work[kind]
+	switch kind {
+	case "bandwidth":
+		src, dst := make([]byte, bandwidthBuffer), make([]byte, bandwidthBuffer)
+		const chunk = 1 << 20
+		for off := 0; !l.stop.Load(); off = (off + chunk) % bandwidthBuffer {
+			copy(dst[off:off+chunk], src[off:off+chunk])
+			done.Add(chunk)
+		}
+	case "cpu":
+		x := uint64(1)
+		for !l.stop.Load() {
+			for range 1 << 16 {
+				x = x*6364136223846793005 + 1442695040888963407
+			}
+			done.Add(1 << 16)
+		}
+		spinSink.Store(x)
+	case "alloc":
+		var live [1024][]byte
+		for i := 0; !l.stop.Load(); i++ {
+			n := 16 << rand.IntN(12)
+			live[i%len(live)] = make([]byte, n)
+			done.Add(int64(n))
+		}
+	}
+}
+
+var spinSink atomic.Uint64
+
+// Stop stops the workers, restores GOMAXPROCS and returns a summary of the
+// work they did and of the CPUs the scenarios kept, e.g. "bandwidth 9.1
+// GB/s, cpu 2.1e+09 it/s, scenarios on CPUs [0 1]".
+func (l *Load) Stop() string {
+	l.stop.Store(true)
+	l.wg.Wait()
+	runtime.GOMAXPROCS(l.procs)
+	var parts []string
+	if l.allowed != nil {
+		if err := shard.Pin(l.allowed...); err != nil {
+			parts = append(parts, "CPUs not restored: "+err.Error())
+		}
+	}
+	elapsed := time.Since(l.start).Seconds()
+	for _, kind := range []string{"bandwidth", "cpu", "alloc"} {
+		w := l.work[kind]
+		if w == nil {
+			continue
+		}
+		rate := float64(w.Load()) / elapsed
+		switch kind {
+		case "bandwidth":
+			parts = append(parts, fmt.Sprintf("bandwidth %.1f GB/s copied", rate/1e9))
+		case "cpu":
+			parts = append(parts, fmt.Sprintf("cpu %.3g it/s", rate))
+		case "alloc":
+			parts = append(parts, fmt.Sprintf("alloc %.2f GB/s allocated", rate/1e9))
+		}
+	}
+	if l.CPUs != nil {
+		parts = append(parts, fmt.Sprintf("scenarios on CPUs %v", l.CPUs))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,53 @@
+package noise
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/shard"
+)
+
+func TestParse(t *testing.T) {
+	specs, err := Parse("bandwidth:2, cpu,alloc:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 3 || specs[0] != (Spec{"bandwidth", 2}) || specs[1] != (Spec{"cpu", 1}) || specs[2].String() != "alloc:1" {
+		t.Errorf("Parse = %v", specs)
+	}
+	for _, bad := range []string{"disk", "cpu:0", "cpu:x", ""} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	cpus, _ := shard.Allowed()
+	l := Start([]Spec{{"cpu", 1}, {"alloc", 1}})
+	if got := runtime.GOMAXPROCS(0); got != procs+2 {
+		t.Errorf("GOMAXPROCS = %d during the load, want %d", got, procs+2)
+	}
+	time.Sleep(20 * time.Millisecond)
+	summary := l.Stop()
+	if runtime.GOMAXPROCS(0) != procs {
+		t.Errorf("GOMAXPROCS not restored")
+	}
+	if after, _ := shard.Allowed(); !slices.Equal(after, cpus) {
+		t.Errorf("CPUs = %v after the load, want %v", after, cpus)
+	}
+	if l.CPUs != nil && (len(l.CPUs) != max(len(cpus)-2, 1) || !slices.Equal(l.CPUs, cpus[:len(l.CPUs)])) {
+		t.Errorf("scenarios on CPUs %v of %v next to 2 workers", l.CPUs, cpus)
+	}
+	if l.CPUs != nil && !strings.Contains(summary, fmt.Sprintf("scenarios on CPUs %v", l.CPUs)) {
+		t.Errorf("summary = %q, want the scenarios' CPUs", summary)
+	}
+	if !strings.Contains(summary, "cpu") || !strings.Contains(summary, "alloc") || strings.Contains(summary, "bandwidth") {
+		t.Errorf("summary = %q", summary)
+	}
+}
@@ -0,0 +1,546 @@
+// Package paramloc reads the DWARF debug information of a linked binary and
+// reports where the parameters of its functions live: in which registers,
+// or at which offset of the stack, over which part of each function's code.
+// It cross-checks what the compiler's listings show of the register-based
+// calling convention with what the debugger is told.
+//
+// Only ELF binaries built by the gc toolchain are read. Location lists are
+// decoded from .debug_loclists (DWARF 5) or .debug_loc (DWARF 4), which the
+// debug/dwarf package leaves to its callers.
+package paramloc
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Piece is where a value, or the part of it Size bytes long, lives.
+type Piece struct {
+	Reg    string `json:"reg,omitempty"`    // a register, e.g. "AX"
+	Stack  bool   `json:"stack,omitempty"`  // at Offset from the canonical frame address
+	Offset int64  `json:"offset,omitempty"` // the caller's stack pointer before the call
+	Size   int64  `json:"size,omitempty"`   // 0 for the whole value
+}
+
+func (p Piece) String() string {
+	switch {
+	case p.Reg != "":
+		return p.Reg
+	case p.Stack:
+		return fmt.Sprintf("CFA%+d", p.Offset)
+	}
+	return "-" // optimized out
+}
+
+// Location is where a value lives at some point of the code. It is empty
+// when the value is not available there.
+type Location []Piece
+
+func (l Location) String() string {
+	switch {
+	case len(l) == 0:
+		return "-"
+	case len(l) == 1 && l[0].Size == 0:
+		return l[0].String()
+	}
+	s := make([]string, len(l))
+	for i, p := range l {
+		s[i] = fmt.Sprintf("%s:%d", p, p.Size)
+	}
+	return strings.Join(s, " ")
+}
+
+// Kind summarizes the location: "registers", "stack", "mixed", or "none"
+// when no piece of the value is available.
+func (l Location) Kind() string {
+	var regs, stack bool
+	for _, p := range l {
+		regs = regs || p.Reg != ""
+		stack = stack || p.Stack
+	}
+	switch {
+	case regs && stack:
+		return "mixed"
+	case regs:
+		return "registers"
+	case stack:
+		return "stack"
+	}
+	return "none"
+}
+
+// Range is an entry of a location list, the location over [Low, High),
+// offsets from the entry of the function.
+type Range struct {
+	Low  uint64   `json:"low"`
+	High uint64   `json:"high"`
+	Loc  Location `json:"location"`
+}
+
+// Param is a parameter or a named or unnamed ("~r0") result.
+type Param struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Size   int64    `json:"size"`
+	Result bool     `json:"result,omitempty"`
+	Entry  Location `json:"entry"`          // where it lives when the function is entered
+	List   []Range  `json:"list,omitempty"` // nil when Entry holds for the whole function
+}
+
+// Func is a function with its parameters in declaration order.
+type Func struct {
+	Name   string  `json:"name"`
+	Entry  uint64  `json:"entry"`
+	Params []Param `json:"params"`
+}
+
+// Read returns the functions of the binary for whose symbol match reports
+// true, in the order of the debug information.
+func Read(file string, match func(name string) bool) ([]*Func, error) {
+	f, err := elf.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := f.DWARF()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	rd := &reader{d: d, order: f.ByteOrder, addrSize: 8}
+	if f.Class == elf.ELFCLASS32 {
+		rd.addrSize = 4
+	}
+	switch f.Machine {
+	case elf.EM_X86_64:
+		rd.regs = amd64Regs
+	case elf.EM_AARCH64:
+		rd.regs = arm64Regs
+	}
+	for name, dst := range map[string]*[]byte{".debug_loclists": &rd.loclists, ".debug_loc": &rd.loc, ".debug_addr": &rd.addr} {
+		if s := f.Section(name); s != nil {
+			if *dst, err = s.Data(); err != nil {
+				return nil, fmt.Errorf("%s: %s: %v", file, name, err)
+			}
+		}
+	}
+
+	var (
+		funcs []*Func
+		cu    *dwarf.Entry
+	)
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			return funcs, nil
+		}
+		switch e.Tag {
+		case dwarf.TagCompileUnit:
+			cu = e
+			continue // into the unit's children
+		case dwarf.TagSubprogram:
+			fn, err := rd.function(r, e, cu, match)
+			if err != nil {
+				return nil, err
+			}
+			if fn != nil {
+				funcs = append(funcs, fn)
+			}
+			continue
+		}
+		if e.Children {
+			r.SkipChildren()
+		}
+	}
+}
+
+type reader struct {
+	d        *dwarf.Data
+	order    binary.ByteOrder
+	addrSize int
+	regs     []string
+	loclists []byte // DWARF 5
+	loc      []byte // DWARF 4
+	addr     []byte
+}
+
+// function reads the subprogram e and its parameters, leaving r after its
+// children. It returns nil for abstract subprograms, which describe an
+// inlined function rather than code, and for those not matched.
+func (rd *reader) function(r *dwarf.Reader, e, cu *dwarf.Entry, match func(string) bool) (*Func, error) {
+	name, _ := rd.attr(e, dwarf.AttrName).(string)
+	low, ok := e.Val(dwarf.AttrLowpc).(uint64)
+	if !ok || !match(name) {
+		if e.Children {
+			r.SkipChildren()
+		}
+		return nil, nil
+	}
+	fn := &Func{Name: name, Entry: low}
+	if !e.Children {
+		return fn, nil
+	}
+	for {
+		c, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if c == nil || c.Tag == 0 {
+			return fn, nil
+		}
+		if c.Tag == dwarf.TagFormalParameter {
+			p, err := rd.param(c, cu, low)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			fn.Params = append(fn.Params, p)
+		}
+		if c.Children {
+			r.SkipChildren() // lexical blocks and inlined calls
+		}
+	}
+}
+
+func (rd *reader) param(e, cu *dwarf.Entry, entry uint64) (Param, error) {
+	p := Param{}
+	p.Name, _ = rd.attr(e, dwarf.AttrName).(string)
+	p.Result, _ = rd.attr(e, dwarf.AttrVarParam).(bool)
+	if off, ok := rd.attr(e, dwarf.AttrType).(dwarf.Offset); ok {
+		t, err := rd.d.Type(off)
+		if err != nil {
+			return p, err
+		}
+		p.Type, p.Size = typeName(t), t.Size()
+	}
+	field := e.AttrField(dwarf.AttrLocation)
+	if field == nil {
+		return p, nil
+	}
+	switch field.Class {
+	case dwarf.ClassExprLoc:
+		loc, err := rd.expr(field.Val.([]byte))
+		p.Entry = loc
+		return p, err
+	case dwarf.ClassLocListPtr:
+		list, err := rd.list(field.Val.(int64), cu)
+		if err != nil {
+			return p, fmt.Errorf("%s: %v", p.Name, err)
+		}
+		for _, rg := range list {
+			if rg.Low < entry || rg.High <= rg.Low {
+				continue
+			}
+			rg.Low, rg.High = rg.Low-entry, rg.High-entry
+			if rg.Low == 0 {
+				p.Entry = rg.Loc
+			}
+			p.List = append(p.List, rg)
+		}
+	}
+	return p, nil
+}
+
+// typeName returns the name the compiler gave t, e.g. "[]int" or "main.T".
+// String would prefix the names of the structs the compiler emits for
+// slices, strings and struct types with "struct", and spell out anonymous
+// types it did not name.
+func typeName(t dwarf.Type) string {
+	switch t := t.(type) {
+	case *dwarf.StructType:
+		if t.StructName != "" {
+			return t.StructName
+		}
+	case *dwarf.PtrType:
+		if t.Name == "" {
+			return "*" + typeName(t.Type)
+		}
+	}
+	if name := t.Common().Name; name != "" {
+		return name
+	}
+	return t.String()
+}
+
+// attr returns the attribute of e or, for the concrete instance of an
+// inlinable function, of the abstract entry it refers to.
+func (rd *reader) attr(e *dwarf.Entry, a dwarf.Attr) any {
+	if v := e.Val(a); v != nil {
+		return v
+	}
+	origin, ok := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+	if !ok {
+		return nil
+	}
+	r := rd.d.Reader()
+	r.Seek(origin)
+	o, err := r.Next()
+	if err != nil || o == nil {
+		return nil
+	}
+	return o.Val(a)
+}
+
+// list decodes the location list at off, with absolute addresses.
+func (rd *reader) list(off int64, cu *dwarf.Entry) ([]Range, error) {
+	base, _ := cu.Val(dwarf.AttrLowpc).(uint64)
+	if rd.loclists != nil {
+		addrBase, _ := cu.Val(dwarf.AttrAddrBase).(int64)
+		return rd.listV5(off, base, addrBase)
+	}
+	return rd.listV4(off, base)
+}
+
+// DWARF 5 location list entries.
+const (
+	lleEndOfList       = 0x00
+	lleBaseAddressx    = 0x01
+	lleStartxEndx      = 0x02
+	lleStartxLength    = 0x03
+	lleOffsetPair      = 0x04
+	lleDefaultLocation = 0x05
+	lleBaseAddress     = 0x06
+	lleStartEnd        = 0x07
+	lleStartLength     = 0x08
+)
+
+func (rd *reader) listV5(off int64, base uint64, addrBase int64) ([]Range, error) {
+	if off < 0 || off >= int64(len(rd.loclists)) {
+		return nil, fmt.Errorf("location list offset %#x out of range", off)
+	}
+	b := &buf{b: rd.loclists[off:], order: rd.order, addrSize: rd.addrSize}
+	addrx := func(i uint64) uint64 {
+		a := &buf{order: rd.order, addrSize: rd.addrSize}
+		if at := uint64(addrBase) + i*uint64(rd.addrSize); at < uint64(len(rd.addr)) {
+			a.b = rd.addr[at:]
+		}
+		v := a.address()
+		if a.err != nil {
+			b.err = a.err
+		}
+		return v
+	}
+	var list []Range
+	for b.err == nil {
+		var low, high uint64
+		switch kind := b.u8(); kind {
+		case lleEndOfList:
+			return list, b.err
+		case lleBaseAddressx:
+			base = addrx(b.uleb())
+			continue
+		case lleBaseAddress:
+			base = b.address()
+			continue
+		case lleStartxEndx:
+			low = addrx(b.uleb())
+			high = addrx(b.uleb())
+		case lleStartxLength:
+			low = addrx(b.uleb())
+			high = low + b.uleb()
+		case lleOffsetPair:
+			low = base + b.uleb()
+			high = base + b.uleb()
+		case lleDefaultLocation:
+			low, high = 0, ^uint64(0)
+		case lleStartEnd:
+			low = b.address()
+			high = b.address()
+		case lleStartLength:
+			low = b.address()
+			high = low + b.uleb()
+		default:
+			return nil, fmt.Errorf("unknown location list entry %#x", kind)
+		}
+		loc, err := rd.expr(b.bytes(int(b.uleb())))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, Range{Low: low, High: high, Loc: loc})
+	}
+	return nil, b.err
+}
+
+func (rd *reader) listV4(off int64, base uint64) ([]Range, error) {
+	if off < 0 || off >= int64(len(rd.loc)) {
+		return nil, fmt.Errorf("location list offset %#x out of range", off)
+	}
+	b := &buf{b: rd.loc[off:], order: rd.order, addrSize: rd.addrSize}
+	maxAddr := ^uint64(0) >> (64 - 8*rd.addrSize)
+	var list []Range
+	for b.err == nil {
+		low, high := b.address(), b.address()
+		switch {
+		case low == 0 && high == 0:
+			return list, b.err
+		case low == maxAddr:
+			base = high
+			continue
+		}
+		loc, err := rd.expr(b.bytes(int(b.u16())))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, Range{Low: base + low, High: base + high, Loc: loc})
+	}
+	return nil, b.err
+}
+
+// DWARF expression operations the gc toolchain uses for parameters.
+const (
+	opReg0         = 0x50
+	opReg31        = 0x6f
+	opRegx         = 0x90
+	opFbreg        = 0x91
+	opPiece        = 0x93
+	opCallFrameCFA = 0x9c
+)
+
+// expr decodes a location expression. The frame base of functions
+// compiled by gc is the canonical frame address, so DW_OP_fbreg offsets are
+// relative to it.
+func (rd *reader) expr(e []byte) (Location, error) {
+	var (
+		loc Location
+		cur Piece
+	)
+	b := &buf{b: e, order: rd.order, addrSize: rd.addrSize}
+	for len(b.b) > 0 && b.err == nil {
+		switch op := b.u8(); {
+		case op >= opReg0 && op <= opReg31:
+			cur.Reg = rd.reg(uint64(op - opReg0))
+		case op == opRegx:
+			cur.Reg = rd.reg(b.uleb())
+		case op == opCallFrameCFA:
+			cur.Stack = true
+		case op == opFbreg:
+			cur.Stack, cur.Offset = true, b.sleb()
+		case op == opPiece:
+			cur.Size = int64(b.uleb())
+			loc = append(loc, cur)
+			cur = Piece{}
+		default:
+			return nil, fmt.Errorf("unsupported location operation %#x", op)
+		}
+	}
+	if cur.Reg != "" || cur.Stack {
+		loc = append(loc, cur)
+	}
+	return loc, b.err
+}
+
+func (rd *reader) reg(n uint64) string {
+	if n < uint64(len(rd.regs)) && rd.regs[n] != "" {
+		return rd.regs[n]
+	}
+	return fmt.Sprintf("r%d", n)
+}
+
+// The DWARF register numbers, by the names of the Go assembler.
+var (
+	amd64Regs = []string{
+		"AX", "DX", "CX", "BX", "SI", "DI", "BP", "SP",
+		"R8", "R9", "R10", "R11", "R12", "R13", "R14", "R15", "",
+		"X0", "X1", "X2", "X3", "X4", "X5", "X6", "X7",
+		"X8", "X9", "X10", "X11", "X12", "X13", "X14", "X15",
+	}
+	arm64Regs = func() []string {
+		regs := make([]string, 96)
+		for i := range 31 {
+			regs[i] = fmt.Sprintf("R%d", i)
+		}
+		regs[31] = "RSP"
+		for i := range 32 {
+			regs[64+i] = fmt.Sprintf("F%d", i)
+		}
+		return regs
+	}()
+)
+
+var errTruncated = errors.New("truncated debug information")
+
+// buf decodes the encodings of DWARF sections. The first error sticks.
+type buf struct {
+	b        []byte
+	order    binary.ByteOrder
+	addrSize int
+	err      error
+}
+
+func (b *buf) bytes(n int) []byte {
+	if b.err != nil || n < 0 || n > len(b.b) {
+		b.err = errTruncated
+		return nil
+	}
+	v := b.b[:n]
+	b.b = b.b[n:]
+	return v
+}
+
+func (b *buf) u8() byte {
+	if v := b.bytes(1); v != nil {
+		return v[0]
+	}
+	return 0
+}
+
+func (b *buf) u16() uint16 {
+	if v := b.bytes(2); v != nil {
+		return b.order.Uint16(v)
+	}
+	return 0
+}
+
+func (b *buf) address() uint64 {
+	v := b.bytes(b.addrSize)
+	switch {
+	case v == nil:
+		return 0
+	case b.addrSize == 4:
+		return uint64(b.order.Uint32(v))
+	}
+	return b.order.Uint64(v)
+}
+
+func (b *buf) uleb() uint64 {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		c := b.u8()
+		if b.err != nil {
+			return 0
+		}
+		v |= uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return v
+		}
+	}
+}
+
+func (b *buf) sleb() int64 {
+	var (
+		v     int64
+		shift uint
+		c     byte
+	)
+	for {
+		c = b.u8()
+		if b.err != nil {
+			return 0
+		}
+		v |= int64(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			break
+		}
+	}
+	if shift < 64 && c&0x40 != 0 {
+		v |= -1 << shift
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+package paramloc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+)
+
+func TestExpr(t *testing.T) {
+	rd := &reader{order: binary.LittleEndian, addrSize: 8, regs: amd64Regs}
+	tests := []struct {
+		expr []byte
+		want string
+		kind string
+	}{
+		{[]byte{opReg0, opPiece, 8, opReg0 + 3, opPiece, 8}, "AX:8 BX:8", "registers"},
+		{[]byte{opPiece, 8, opReg0 + 3, opPiece, 8}, "-:8 BX:8", "registers"},
+		{[]byte{opReg0 + 17}, "X0", "registers"},
+		{[]byte{opCallFrameCFA}, "CFA+0", "stack"},
+		{[]byte{opFbreg, 0x80, 0x02}, "CFA+256", "stack"},
+		{[]byte{opFbreg, 0x78}, "CFA-8", "stack"},
+		{[]byte{opReg0, opPiece, 8, opFbreg, 8, opPiece, 8}, "AX:8 CFA+8:8", "mixed"},
+		{nil, "-", "none"},
+	}
+	for _, tt := range tests {
+		loc, err := rd.expr(tt.expr)
+		if err != nil {
+			t.Errorf("expr(%x): %v", tt.expr, err)
+			continue
+		}
+		if loc.String() != tt.want || loc.Kind() != tt.kind {
+			t.Errorf("expr(%x) = %s (%s), want %s (%s)", tt.expr, loc, loc.Kind(), tt.want, tt.kind)
+		}
+	}
+	if _, err := rd.expr([]byte{opFbreg}); err == nil {
+		t.Error("truncated expression decoded without error")
+	}
+}
+
+func TestListV5(t *testing.T) {
+	rd := &reader{order: binary.LittleEndian, addrSize: 8, regs: amd64Regs}
+	// The base comes from .debug_addr, as the gc linker writes it.
+	rd.addr = make([]byte, 24)
+	binary.LittleEndian.PutUint64(rd.addr[16:], 0x1000)
+	rd.loclists = []byte{
+		0xff, // padding before the list
+		lleBaseAddressx, 1,
+		lleOffsetPair, 0x00, 0x03, 5, opReg0, opPiece, 8, opPiece, 8,
+		lleOffsetPair, 0x03, 0x04, 5, opPiece, 8, opReg0 + 3, opPiece, 8,
+		lleEndOfList,
+	}
+	list, err := rd.listV5(1, 0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range list {
+		got = append(got, fmt.Sprintf("%#x %#x %s", r.Low, r.High, r.Loc))
+	}
+	want := "0x1000 0x1003 AX:8 -:8, 0x1003 0x1004 -:8 BX:8"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("list = %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestRead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a test binary")
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("reads ELF binaries only")
+	}
+	if runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64" {
+		t.Skip("expects the register-based calling convention of amd64 and arm64")
+	}
+	bin := filepath.Join(t.TempDir(), "params.test")
+	if err := asm.BuildTest("testdata/params", bin); err != nil {
+		t.Fatal(err)
+	}
+	const prefix = "github.com/rohanchauhan02/valuevspointer/internal/paramloc/testdata/params."
+	funcs, err := Read(bin, func(name string) bool { return strings.HasPrefix(name, prefix) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := make(map[string]Param)
+	for _, fn := range funcs {
+		if len(fn.Params) > 0 {
+			params[strings.TrimPrefix(fn.Name, prefix)] = fn.Params[0]
+		}
+	}
+	// A struct of two words arrives in two registers, a pointer in one;
+	// a large struct, and any array of more than one element, on the
+	// caller's stack.
+	tests := map[string]struct {
+		size   int64
+		kind   string
+		pieces int
+	}{
+		"SmallValue":   {16, "registers", 2},
+		"SmallPointer": {8, "registers", 1},
+		"BigValue":     {1024, "stack", 1},
+		"Pair":         {16, "stack", 1},
+	}
+	for name, want := range tests {
+		p, ok := params[name]
+		if !ok {
+			t.Errorf("%s: no parameters in the debug information", name)
+			continue
+		}
+		if p.Size != want.size || p.Entry.Kind() != want.kind || len(p.Entry) != want.pieces {
+			t.Errorf("%s: %s of %d bytes at %s (%s), want %d bytes in %d piece(s), %s",
+				name, p.Name, p.Size, p.Entry, p.Entry.Kind(), want.size, want.pieces, want.kind)
+		}
+	}
+	for name, want := range map[string]string{
+		"SmallValue":   "github.com/rohanchauhan02/valuevspointer/internal/paramloc/testdata/params.Small",
+		"SmallPointer": "*github.com/rohanchauhan02/valuevspointer/internal/paramloc/testdata/params.Small",
+		"Slice":        "[]int64",
+		"AnonPointer":  "*struct { A int64; B int64 }",
+	} {
+		if got := params[name].Type; got != want {
+			t.Errorf("%s: type %q, want %q", name, got, want)
+		}
+	}
+	if p := params["BigValue"]; len(p.Entry) == 1 && p.Entry[0].Offset != 0 {
+		t.Errorf("BigValue: b at %s, want the start of the arguments, CFA+0", p.Entry)
+	}
+}
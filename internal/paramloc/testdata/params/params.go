@@ -0,0 +1,25 @@
+package params
+
+type Small struct{ A, B int64 }
+
+type Big struct{ Buf [1024]byte }
+
+var sink int64
+
+//go:noinline
+func SmallValue(s Small) { sink = s.A + s.B }
+
+//go:noinline
+func SmallPointer(s *Small) { sink = s.A + s.B }
+
+//go:noinline
+func BigValue(b Big) { sink = int64(b.Buf[0]) }
+
+//go:noinline
+func Pair(p [2]int64) { sink = p[0] + p[1] }
+
+//go:noinline
+func Slice(s []int64) { sink = s[0] }
+
+//go:noinline
+func AnonPointer(p *struct{ A, B int64 }) { sink = p.A + p.B }
@@ -0,0 +1,12 @@
+package params
+
+import "testing"
+
+func TestCalls(t *testing.T) {
+	SmallValue(Small{1, 2})
+	SmallPointer(&Small{1, 2})
+	BigValue(Big{})
+	Pair([2]int64{1, 2})
+	Slice([]int64{1})
+	AnonPointer(&struct{ A, B int64 }{1, 2})
+}
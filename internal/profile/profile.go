@@ -0,0 +1,428 @@
+// Package profile decodes pprof profiles (profile.proto, optionally gzipped)
+// without depending on github.com/google/pprof. Only the parts the reports
+// in this module need are kept.
+package profile
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Profile is a decoded pprof profile.
+type Profile struct {
+	SampleType        []ValueType
+	DefaultSampleType string
+	Sample            []*Sample
+	Location          []*Location
+	Function          []*Function
+	PeriodType        ValueType
+	Period            int64
+	TimeNanos         int64
+	DurationNanos     int64
+}
+
+// ValueType describes the values of a sample, e.g. "cpu"/"nanoseconds".
+type ValueType struct {
+	Type, Unit string
+}
+
+// Sample is a stack with its values; Location[0] is the leaf.
+type Sample struct {
+	Location []*Location
+	Value    []int64
+}
+
+// Location is a program counter. When functions were inlined it has one
+// Line per function, innermost first.
+type Location struct {
+	ID      uint64
+	Address uint64
+	Line    []Line
+}
+
+// Line is a source position inside a function.
+type Line struct {
+	Function *Function
+	Line     int64
+}
+
+// Function is a function referenced by the profile.
+type Function struct {
+	ID         uint64
+	Name       string
+	SystemName string
+	Filename   string
+	StartLine  int64
+}
+
+// Frame is one function of a flattened stack.
+type Frame struct {
+	Function string
+	File     string
+	Line     int64
+}
+
+// Stack flattens the locations of s into functions, leaf first, expanding
+// inlined calls.
+func (s *Sample) Stack() []Frame {
+	var frames []Frame
+	for _, loc := range s.Location {
+		for _, ln := range loc.Line {
+			f := Frame{Line: ln.Line}
+			if ln.Function != nil {
+				f.Function = ln.Function.Name
+				f.File = ln.Function.Filename
+			}
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
+
+// Index returns the index of the sample value of the given type, or -1.
+// An empty name selects the default sample type, which is the last one
+// when the profile does not say otherwise.
+func (p *Profile) Index(name string) int {
+	if name == "" {
+		name = p.DefaultSampleType
+	}
+	if name == "" {
+		return len(p.SampleType) - 1
+	}
+	for i, st := range p.SampleType {
+		if st.Type == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Parse decodes a profile from r.
+func Parse(r io.Reader) (*Profile, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		r = gz
+	} else {
+		r = br
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("profile: %v", err)
+	}
+	return p, nil
+}
+
+type rawSample struct {
+	locs   []uint64
+	values []int64
+}
+
+type rawLine struct {
+	fn   uint64
+	line int64
+}
+
+type rawLocation struct {
+	id, addr uint64
+	lines    []rawLine
+}
+
+type rawFunction struct {
+	id                         uint64
+	name, sysName, file, start int64
+}
+
+type rawValueType struct{ typ, unit int64 }
+
+func decode(data []byte) (*Profile, error) {
+	var (
+		strs        []string
+		sampleTypes []rawValueType
+		periodType  rawValueType
+		defaultType int64
+		samples     []rawSample
+		locs        []rawLocation
+		funcs       []rawFunction
+		p           = new(Profile)
+	)
+	err := fields(data, func(num int, wire int, v uint64, b []byte) error {
+		var err error
+		switch num {
+		case 1:
+			var vt rawValueType
+			vt, err = decodeValueType(b)
+			sampleTypes = append(sampleTypes, vt)
+		case 2:
+			var s rawSample
+			s, err = decodeSample(b)
+			samples = append(samples, s)
+		case 4:
+			var l rawLocation
+			l, err = decodeLocation(b)
+			locs = append(locs, l)
+		case 5:
+			var f rawFunction
+			f, err = decodeFunction(b)
+			funcs = append(funcs, f)
+		case 6:
+			strs = append(strs, string(b))
+		case 9:
+			p.TimeNanos = int64(v)
+		case 10:
+			p.DurationNanos = int64(v)
+		case 11:
+			periodType, err = decodeValueType(b)
+		case 12:
+			p.Period = int64(v)
+		case 14:
+			defaultType = int64(v)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	str := func(i int64) (string, error) {
+		if i < 0 || i >= int64(len(strs)) {
+			return "", fmt.Errorf("string index %d out of range", i)
+		}
+		return strs[i], nil
+	}
+	vt := func(r rawValueType) (ValueType, error) {
+		t, err := str(r.typ)
+		if err != nil {
+			return ValueType{}, err
+		}
+		u, err := str(r.unit)
+		return ValueType{t, u}, err
+	}
+
+	for _, r := range sampleTypes {
+		t, err := vt(r)
+		if err != nil {
+			return nil, err
+		}
+		p.SampleType = append(p.SampleType, t)
+	}
+	if len(strs) > 0 {
+		if p.PeriodType, err = vt(periodType); err != nil {
+			return nil, err
+		}
+		if p.DefaultSampleType, err = str(defaultType); err != nil {
+			return nil, err
+		}
+	}
+
+	fnByID := make(map[uint64]*Function)
+	for _, r := range funcs {
+		f := &Function{ID: r.id, StartLine: r.start}
+		if f.Name, err = str(r.name); err != nil {
+			return nil, err
+		}
+		if f.SystemName, err = str(r.sysName); err != nil {
+			return nil, err
+		}
+		if f.Filename, err = str(r.file); err != nil {
+			return nil, err
+		}
+		fnByID[f.ID] = f
+		p.Function = append(p.Function, f)
+	}
+	locByID := make(map[uint64]*Location)
+	for _, r := range locs {
+		l := &Location{ID: r.id, Address: r.addr}
+		for _, rl := range r.lines {
+			l.Line = append(l.Line, Line{Function: fnByID[rl.fn], Line: rl.line})
+		}
+		locByID[l.ID] = l
+		p.Location = append(p.Location, l)
+	}
+	for _, r := range samples {
+		s := &Sample{Value: r.values}
+		for _, id := range r.locs {
+			l := locByID[id]
+			if l == nil {
+				return nil, fmt.Errorf("sample references unknown location %d", id)
+			}
+			s.Location = append(s.Location, l)
+		}
+		p.Sample = append(p.Sample, s)
+	}
+	return p, nil
+}
+
+func decodeValueType(b []byte) (rawValueType, error) {
+	var vt rawValueType
+	err := fields(b, func(num, wire int, v uint64, _ []byte) error {
+		switch num {
+		case 1:
+			vt.typ = int64(v)
+		case 2:
+			vt.unit = int64(v)
+		}
+		return nil
+	})
+	return vt, err
+}
+
+func decodeSample(b []byte) (rawSample, error) {
+	var s rawSample
+	err := fields(b, func(num, wire int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			return repeated(wire, v, b, func(v uint64) { s.locs = append(s.locs, v) })
+		case 2:
+			return repeated(wire, v, b, func(v uint64) { s.values = append(s.values, int64(v)) })
+		}
+		return nil
+	})
+	return s, err
+}
+
+func decodeLocation(b []byte) (rawLocation, error) {
+	var l rawLocation
+	err := fields(b, func(num, wire int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			l.id = v
+		case 3:
+			l.addr = v
+		case 4:
+			var ln rawLine
+			err := fields(b, func(num, wire int, v uint64, _ []byte) error {
+				switch num {
+				case 1:
+					ln.fn = v
+				case 2:
+					ln.line = int64(v)
+				}
+				return nil
+			})
+			l.lines = append(l.lines, ln)
+			return err
+		}
+		return nil
+	})
+	return l, err
+}
+
+func decodeFunction(b []byte) (rawFunction, error) {
+	var f rawFunction
+	err := fields(b, func(num, wire int, v uint64, _ []byte) error {
+		switch num {
+		case 1:
+			f.id = v
+		case 2:
+			f.name = int64(v)
+		case 3:
+			f.sysName = int64(v)
+		case 4:
+			f.file = int64(v)
+		case 5:
+			f.start = int64(v)
+		}
+		return nil
+	})
+	return f, err
+}
+
+const (
+	wireVarint = 0
+	wire64     = 1
+	wireBytes  = 2
+	wire32     = 5
+)
+
+var errTruncated = errors.New("truncated message")
+
+// fields calls fn for every field of the protobuf message in b. Varint and
+// fixed fields are passed in v, length delimited fields in b.
+func fields(b []byte, fn func(num, wire int, v uint64, b []byte) error) error {
+	for len(b) > 0 {
+		key, n := varint(b)
+		if n == 0 {
+			return errTruncated
+		}
+		b = b[n:]
+		num, wire := int(key>>3), int(key&7)
+		var v uint64
+		var field []byte
+		switch wire {
+		case wireVarint:
+			v, n = varint(b)
+			if n == 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case wire64:
+			if len(b) < 8 {
+				return errTruncated
+			}
+			for i := 7; i >= 0; i-- {
+				v = v<<8 | uint64(b[i])
+			}
+			b = b[8:]
+		case wire32:
+			if len(b) < 4 {
+				return errTruncated
+			}
+			for i := 3; i >= 0; i-- {
+				v = v<<8 | uint64(b[i])
+			}
+			b = b[4:]
+		case wireBytes:
+			l, n := varint(b)
+			if n == 0 || uint64(len(b)-n) < l {
+				return errTruncated
+			}
+			field = b[n : n+int(l)]
+			b = b[n+int(l):]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+		if err := fn(num, wire, v, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repeated decodes a repeated varint field, which may be packed.
+func repeated(wire int, v uint64, b []byte, add func(uint64)) error {
+	if wire != wireBytes {
+		add(v)
+		return nil
+	}
+	for len(b) > 0 {
+		x, n := varint(b)
+		if n == 0 {
+			return errTruncated
+		}
+		add(x)
+		b = b[n:]
+	}
+	return nil
+}
+
+func varint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
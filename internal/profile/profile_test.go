@@ -0,0 +1,62 @@
+package profile
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+var sink []*[1 << 16]byte
+
+//go:noinline
+func allocate() {
+	for range 64 {
+		sink = append(sink, new([1 << 16]byte))
+	}
+}
+
+func TestParseHeap(t *testing.T) {
+	old := runtime.MemProfileRate
+	runtime.MemProfileRate = 1
+	defer func() { runtime.MemProfileRate = old }()
+	allocate()
+	runtime.GC()
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := p.Index("alloc_space"); i < 0 || p.SampleType[i].Unit != "bytes" {
+		t.Fatalf("sample types = %v, want alloc_space in bytes", p.SampleType)
+	}
+	if p.Index("") < 0 {
+		t.Errorf("no default sample type in %v", p.SampleType)
+	}
+
+	found := false
+	for _, s := range p.Sample {
+		for _, f := range s.Stack() {
+			if strings.HasSuffix(f.Function, "profile.allocate") {
+				found = true
+				if !strings.HasSuffix(f.File, "profile_test.go") || f.Line == 0 {
+					t.Errorf("allocate frame = %+v, want a position in profile_test.go", f)
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("no sample with the allocate frame")
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	if _, err := Parse(bytes.NewReader([]byte{0x0a, 0x05, 0x08})); err == nil {
+		t.Error("Parse accepted a truncated message")
+	}
+}
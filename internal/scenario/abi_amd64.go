@@ -0,0 +1,67 @@
+package scenario
+
+import "unsafe"
+
+// The abi scenarios call functions taking a small struct by value or by
+// pointer. Go functions use the register-based ABIInternal and receive the
+// fields in registers. The assembly functions in abi_amd64.s use ABI0, which
+// passes arguments on the stack: a direct call from Go spills the registers
+// to the stack around the call ("asm"), and a call through a func value goes
+// through the ABIInternal wrapper the linker generates for the function
+// ("asm-func"), which does the same one call deeper.
+func init() {
+	abiScenarios(int64(unsafe.Sizeof(abi16{})),
+		func(v *abi16) { abiSink = goSum16(*v) },
+		func(v *abi16) { abiSink = goSum16Ptr(v) },
+		func(v *abi16) { abiSink = asmSum16(*v) },
+		func(v *abi16) { abiSink = asmSum16Ptr(v) },
+		asmSum16, asmSum16Ptr)
+	abiScenarios(int64(unsafe.Sizeof(abi32{})),
+		func(v *abi32) { abiSink = goSum32(*v) },
+		func(v *abi32) { abiSink = goSum32Ptr(v) },
+		func(v *abi32) { abiSink = asmSum32(*v) },
+		func(v *abi32) { abiSink = asmSum32Ptr(v) },
+		asmSum32, asmSum32Ptr)
+	abiScenarios(int64(unsafe.Sizeof(abi64{})),
+		func(v *abi64) { abiSink = goSum64(*v) },
+		func(v *abi64) { abiSink = goSum64Ptr(v) },
+		func(v *abi64) { abiSink = asmSum64(*v) },
+		func(v *abi64) { abiSink = asmSum64Ptr(v) },
+		asmSum64, asmSum64Ptr)
+}
+
+type abi16 struct{ a, b int64 }
+
+type abi32 struct{ a, b, c, d int64 }
+
+type abi64 struct{ a, b, c, d, e, f, g, h int64 }
+
+var abiSink int64
+
+// Implemented in abi_amd64.s.
+func asmSum16(v abi16) int64
+func asmSum16Ptr(v *abi16) int64
+func asmSum32(v abi32) int64
+func asmSum32Ptr(v *abi32) int64
+func asmSum64(v abi64) int64
+func asmSum64Ptr(v *abi64) int64
+
+// abiScenarios registers the scenarios for one struct type: direct calls of
+// the Go and assembly functions, and calls of the assembly functions
+// through func values.
+func abiScenarios[T any](size int64, goValue, goPointer, asmValue, asmPointer func(*T), funcValue func(T) int64, funcPointer func(*T) int64) {
+	call := func(f func(*T)) func() func() {
+		return func() func() {
+			v := new(T)
+			return func() { f(v) }
+		}
+	}
+	Register(
+		&Scenario{Group: "abi", Variant: "go-value", Size: size, Op: call(goValue)},
+		&Scenario{Group: "abi", Variant: "go-pointer", Size: size, Op: call(goPointer)},
+		&Scenario{Group: "abi", Variant: "asm-value", Size: size, Op: call(asmValue)},
+		&Scenario{Group: "abi", Variant: "asm-pointer", Size: size, Op: call(asmPointer)},
+		&Scenario{Group: "abi", Variant: "asm-func-value", Size: size, Op: call(func(v *T) { abiSink = funcValue(*v) })},
+		&Scenario{Group: "abi", Variant: "asm-func-pointer", Size: size, Op: call(func(v *T) { abiSink = funcPointer(v) })},
+	)
+}
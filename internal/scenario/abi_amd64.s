@@ -0,0 +1,65 @@
+#include "textflag.h"
+
+// The sums take their arguments and return their results on the stack, as
+// ABI0 does.
+
+// func asmSum16(v abi16) int64
+TEXT ·asmSum16(SB), NOSPLIT, $0-24
+	MOVQ v_a+0(FP), AX
+	ADDQ v_b+8(FP), AX
+	MOVQ AX, ret+16(FP)
+	RET
+
+// func asmSum16Ptr(v *abi16) int64
+TEXT ·asmSum16Ptr(SB), NOSPLIT, $0-16
+	MOVQ v+0(FP), BX
+	MOVQ 0(BX), AX
+	ADDQ 8(BX), AX
+	MOVQ AX, ret+8(FP)
+	RET
+
+// func asmSum32(v abi32) int64
+TEXT ·asmSum32(SB), NOSPLIT, $0-40
+	MOVQ v_a+0(FP), AX
+	ADDQ v_b+8(FP), AX
+	ADDQ v_c+16(FP), AX
+	ADDQ v_d+24(FP), AX
+	MOVQ AX, ret+32(FP)
+	RET
+
+// func asmSum32Ptr(v *abi32) int64
+TEXT ·asmSum32Ptr(SB), NOSPLIT, $0-16
+	MOVQ v+0(FP), BX
+	MOVQ 0(BX), AX
+	ADDQ 8(BX), AX
+	ADDQ 16(BX), AX
+	ADDQ 24(BX), AX
+	MOVQ AX, ret+8(FP)
+	RET
+
+// func asmSum64(v abi64) int64
+TEXT ·asmSum64(SB), NOSPLIT, $0-72
+	MOVQ v_a+0(FP), AX
+	ADDQ v_b+8(FP), AX
+	ADDQ v_c+16(FP), AX
+	ADDQ v_d+24(FP), AX
+	ADDQ v_e+32(FP), AX
+	ADDQ v_f+40(FP), AX
+	ADDQ v_g+48(FP), AX
+	ADDQ v_h+56(FP), AX
+	MOVQ AX, ret+64(FP)
+	RET
+
+// func asmSum64Ptr(v *abi64) int64
+TEXT ·asmSum64Ptr(SB), NOSPLIT, $0-16
+	MOVQ v+0(FP), BX
+	MOVQ 0(BX), AX
+	ADDQ 8(BX), AX
+	ADDQ 16(BX), AX
+	ADDQ 24(BX), AX
+	ADDQ 32(BX), AX
+	ADDQ 40(BX), AX
+	ADDQ 48(BX), AX
+	ADDQ 56(BX), AX
+	MOVQ AX, ret+8(FP)
+	RET
@@ -0,0 +1,49 @@
+package scenario
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+)
+
+// TestABIWrappers checks in the compiler's output that func values of the
+// ABI0 assembly functions go through generated ABIInternal wrappers, which
+// spill the register arguments to the stack for the call.
+func TestABIWrappers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles the package")
+	}
+	funcs, err := asm.Compile(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := reflect.TypeOf(abi16{}).PkgPath() + ".asmSum16"
+	for _, f := range funcs {
+		if f.Name != name {
+			continue
+		}
+		var text string
+		spills := 0
+		for _, in := range f.Instrs {
+			switch {
+			case in.Op == "TEXT":
+				text = in.Args
+			case in.Op == "MOVQ" && strings.HasSuffix(in.Args, "(SP)"):
+				spills++
+			}
+		}
+		if !strings.Contains(text, "ABIWRAPPER") {
+			t.Errorf("%s is not a wrapper: TEXT %s", name, text)
+		}
+		if spills != 2 {
+			t.Errorf("%s spills %d registers, want 2", name, spills)
+		}
+		if calls := f.Calls(); len(calls) != 1 || calls[0].Target() != name {
+			t.Errorf("%s calls %v, want only the ABI0 %s", name, calls, name)
+		}
+		return
+	}
+	t.Fatalf("no wrapper %s in the listing", name)
+}
@@ -0,0 +1,90 @@
+package scenario
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// The append scenarios build a slice of appendLen elements per operation.
+// Appending to a slice of large values copies every element into each new,
+// larger backing array; a slice of pointers copies only the pointers but
+// allocates every element on its own; a slice made with the final capacity
+// is never copied. Every scenario reports the bytes copied by reallocation
+// and the garbage collector's cost per operation.
+func init() {
+	appendGrowth[[64]byte]()
+	appendGrowth[[1 << 10]byte]()
+	appendGrowth[[8 << 10]byte]()
+}
+
+const appendLen = 256
+
+var appendSink unsafe.Pointer
+
+func appendGrowth[T any]() {
+	var v T
+	size := int64(unsafe.Sizeof(v))
+	// The operations call the builders directly, so that bench -traffic
+	// finds the copies of growing the slice in their code.
+	register := func(variant string, build func() int64, op func()) {
+		Register(&Scenario{Group: "append", Variant: variant, Size: size,
+			Op: func() func() {
+				return op
+			},
+			Bench: func(b *testing.B) {
+				report := gcCost()
+				b.ResetTimer()
+				copied := int64(0)
+				for range b.N {
+					copied = build()
+				}
+				b.StopTimer()
+				report(b)
+				b.ReportMetric(float64(copied), "copied-B/op")
+			},
+		})
+	}
+	register("value", func() int64 { return appendValue(v) }, func() { appendValue(v) })
+	register("pointer", appendPointer[T], func() { appendPointer[T]() })
+	register("presized", func() int64 { return appendPresized(v) }, func() { appendPresized(v) })
+}
+
+// The builders build one slice and return the bytes its reallocations
+// copied.
+
+func appendValue[T any](v T) int64 {
+	size := int64(unsafe.Sizeof(v))
+	var s []T
+	copied := int64(0)
+	for range appendLen {
+		if len(s) == cap(s) {
+			copied += int64(len(s)) * size
+		}
+		s = append(s, v)
+	}
+	appendSink = unsafe.Pointer(unsafe.SliceData(s))
+	return copied
+}
+
+func appendPointer[T any]() int64 {
+	ptr := int64(unsafe.Sizeof(new(T)))
+	var s []*T
+	copied := int64(0)
+	for range appendLen {
+		if len(s) == cap(s) {
+			copied += int64(len(s)) * ptr
+		}
+		s = append(s, new(T))
+	}
+	appendSink = unsafe.Pointer(unsafe.SliceData(s))
+	return copied
+}
+
+func appendPresized[T any](v T) int64 {
+	s := make([]T, 0, appendLen)
+	for range appendLen {
+		s = append(s, v)
+	}
+	appendSink = unsafe.Pointer(unsafe.SliceData(s))
+	return 0
+}
@@ -0,0 +1,70 @@
+//go:build directive_default
+
+package scenario
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// The callees without directives: the compiler inlines those under its
+// budget, and the copy of an argument passed by value may then disappear.
+// See callees_noinline.go.
+const Directive = "default"
+
+func byValue[T any](v T) {}
+
+func byPointer[T any](v *T) {}
+
+func runValue[T any](v T, wg *sync.WaitGroup) {
+	wg.Done()
+}
+
+func runPointer[T any](v *T, wg *sync.WaitGroup) {
+	wg.Done()
+}
+
+func touch[T any](v *T) {}
+
+func touchValue[T any](v T, wg *sync.WaitGroup) {
+	touchPages(unsafe.Pointer(&v), unsafe.Sizeof(v))
+	wg.Done()
+}
+
+func work[T any](m *message[T]) {}
+
+func keepAddr[T any](p *T) { loopKept = unsafe.Pointer(p) }
+
+func useValue[T any](v T) {}
+
+func moveParticle(p particle) particle {
+	p.X += p.VX
+	p.Y += p.VY
+	return p
+}
+
+func moveParticleAt(p *particle) {
+	p.X += p.VX
+	p.Y += p.VY
+}
+
+func (s *particleSoA) move(i int) {
+	s.X[i] += s.VX[i]
+	s.Y[i] += s.VY[i]
+}
+
+func moveBody(b body) body {
+	b.X += b.VX
+	b.Y += b.VY
+	return b
+}
+
+func moveBodyAt(b *body) {
+	b.X += b.VX
+	b.Y += b.VY
+}
+
+func (s *bodySoA) move(i int) {
+	s.X[i] += s.VX[i]
+	s.Y[i] += s.VY[i]
+}
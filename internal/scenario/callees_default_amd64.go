@@ -0,0 +1,15 @@
+//go:build directive_default
+
+package scenario
+
+func goSum16(v abi16) int64 { return v.a + v.b }
+
+func goSum16Ptr(v *abi16) int64 { return v.a + v.b }
+
+func goSum32(v abi32) int64 { return v.a + v.b + v.c + v.d }
+
+func goSum32Ptr(v *abi32) int64 { return v.a + v.b + v.c + v.d }
+
+func goSum64(v abi64) int64 { return v.a + v.b + v.c + v.d + v.e + v.f + v.g + v.h }
+
+func goSum64Ptr(v *abi64) int64 { return v.a + v.b + v.c + v.d + v.e + v.f + v.g + v.h }
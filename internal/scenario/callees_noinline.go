@@ -0,0 +1,94 @@
+//go:build !directive_default && !directive_nosplit
+
+package scenario
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// The callees of the scenarios, the functions a value is passed to, are
+// declared once per directive variant, chosen with a build tag:
+//
+//	(none)             //go:noinline, the default build
+//	directive_default  no directive, so the compiler may inline them
+//	directive_nosplit  //go:noinline and //go:nosplit
+//
+// The scenario names do not change between builds; Directive tells which
+// variant the binary was built with. "bench -directives" runs every
+// scenario in each variant, and cmd/directives shows what the directives do
+// to the frames and stack checks in the compiled code. The variants are
+// kept by hand; TestCallees checks that they declare the same functions.
+const Directive = "noinline"
+
+//go:noinline
+func byValue[T any](v T) {}
+
+//go:noinline
+func byPointer[T any](v *T) {}
+
+//go:noinline
+func runValue[T any](v T, wg *sync.WaitGroup) {
+	wg.Done()
+}
+
+//go:noinline
+func runPointer[T any](v *T, wg *sync.WaitGroup) {
+	wg.Done()
+}
+
+//go:noinline
+func touch[T any](v *T) {}
+
+//go:noinline
+func touchValue[T any](v T, wg *sync.WaitGroup) {
+	touchPages(unsafe.Pointer(&v), unsafe.Sizeof(v))
+	wg.Done()
+}
+
+//go:noinline
+func work[T any](m *message[T]) {}
+
+//go:noinline
+func keepAddr[T any](p *T) { loopKept = unsafe.Pointer(p) }
+
+//go:noinline
+func useValue[T any](v T) {}
+
+//go:noinline
+func moveParticle(p particle) particle {
+	p.X += p.VX
+	p.Y += p.VY
+	return p
+}
+
+//go:noinline
+func moveParticleAt(p *particle) {
+	p.X += p.VX
+	p.Y += p.VY
+}
+
+//go:noinline
+func (s *particleSoA) move(i int) {
+	s.X[i] += s.VX[i]
+	s.Y[i] += s.VY[i]
+}
+
+//go:noinline
+func moveBody(b body) body {
+	b.X += b.VX
+	b.Y += b.VY
+	return b
+}
+
+//go:noinline
+func moveBodyAt(b *body) {
+	b.X += b.VX
+	b.Y += b.VY
+}
+
+//go:noinline
+func (s *bodySoA) move(i int) {
+	s.X[i] += s.VX[i]
+	s.Y[i] += s.VY[i]
+}
@@ -0,0 +1,21 @@
+//go:build !directive_default && !directive_nosplit
+
+package scenario
+
+//go:noinline
+func goSum16(v abi16) int64 { return v.a + v.b }
+
+//go:noinline
+func goSum16Ptr(v *abi16) int64 { return v.a + v.b }
+
+//go:noinline
+func goSum32(v abi32) int64 { return v.a + v.b + v.c + v.d }
+
+//go:noinline
+func goSum32Ptr(v *abi32) int64 { return v.a + v.b + v.c + v.d }
+
+//go:noinline
+func goSum64(v abi64) int64 { return v.a + v.b + v.c + v.d + v.e + v.f + v.g + v.h }
+
+//go:noinline
+func goSum64Ptr(v *abi64) int64 { return v.a + v.b + v.c + v.d + v.e + v.f + v.g + v.h }
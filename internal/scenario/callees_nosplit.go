@@ -0,0 +1,103 @@
+//go:build directive_nosplit
+
+package scenario
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// The callees without the stack check: //go:nosplit drops the prologue that
+// calls runtime.morestack when the stack is too small, which is only legal
+// while the frames of a chain of nosplit functions fit in the small reserve
+// below the stack guard; the linker rejects the build otherwise. The
+// callees keep //go:noinline so that the call, and its copy, stay.
+// See callees_noinline.go.
+const Directive = "nosplit"
+
+//go:noinline
+//go:nosplit
+func byValue[T any](v T) {}
+
+//go:noinline
+//go:nosplit
+func byPointer[T any](v *T) {}
+
+//go:noinline
+//go:nosplit
+func runValue[T any](v T, wg *sync.WaitGroup) {
+	wg.Done()
+}
+
+//go:noinline
+//go:nosplit
+func runPointer[T any](v *T, wg *sync.WaitGroup) {
+	wg.Done()
+}
+
+//go:noinline
+//go:nosplit
+func touch[T any](v *T) {}
+
+//go:noinline
+//go:nosplit
+func touchValue[T any](v T, wg *sync.WaitGroup) {
+	touchPages(unsafe.Pointer(&v), unsafe.Sizeof(v))
+	wg.Done()
+}
+
+//go:noinline
+//go:nosplit
+func work[T any](m *message[T]) {}
+
+//go:noinline
+//go:nosplit
+func keepAddr[T any](p *T) { loopKept = unsafe.Pointer(p) }
+
+//go:noinline
+//go:nosplit
+func useValue[T any](v T) {}
+
+//go:noinline
+//go:nosplit
+func moveParticle(p particle) particle {
+	p.X += p.VX
+	p.Y += p.VY
+	return p
+}
+
+//go:noinline
+//go:nosplit
+func moveParticleAt(p *particle) {
+	p.X += p.VX
+	p.Y += p.VY
+}
+
+//go:noinline
+//go:nosplit
+func (s *particleSoA) move(i int) {
+	s.X[i] += s.VX[i]
+	s.Y[i] += s.VY[i]
+}
+
+//go:noinline
+//go:nosplit
+func moveBody(b body) body {
+	b.X += b.VX
+	b.Y += b.VY
+	return b
+}
+
+//go:noinline
+//go:nosplit
+func moveBodyAt(b *body) {
+	b.X += b.VX
+	b.Y += b.VY
+}
+
+//go:noinline
+//go:nosplit
+func (s *bodySoA) move(i int) {
+	s.X[i] += s.VX[i]
+	s.Y[i] += s.VY[i]
+}
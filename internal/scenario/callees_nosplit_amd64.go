@@ -0,0 +1,27 @@
+//go:build directive_nosplit
+
+package scenario
+
+//go:noinline
+//go:nosplit
+func goSum16(v abi16) int64 { return v.a + v.b }
+
+//go:noinline
+//go:nosplit
+func goSum16Ptr(v *abi16) int64 { return v.a + v.b }
+
+//go:noinline
+//go:nosplit
+func goSum32(v abi32) int64 { return v.a + v.b + v.c + v.d }
+
+//go:noinline
+//go:nosplit
+func goSum32Ptr(v *abi32) int64 { return v.a + v.b + v.c + v.d }
+
+//go:noinline
+//go:nosplit
+func goSum64(v abi64) int64 { return v.a + v.b + v.c + v.d + v.e + v.f + v.g + v.h }
+
+//go:noinline
+//go:nosplit
+func goSum64Ptr(v *abi64) int64 { return v.a + v.b + v.c + v.d + v.e + v.f + v.g + v.h }
@@ -0,0 +1,85 @@
+package scenario
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// TestCallees checks that the directive variants of the callees declare the
+// same functions, with the same bodies, and mark each with the directives
+// of their variant.
+func TestCallees(t *testing.T) {
+	directives := map[string][]string{
+		"noinline": {"//go:noinline"},
+		"default":  nil,
+		"nosplit":  {"//go:noinline", "//go:nosplit"},
+	}
+	for _, suffix := range []string{".go", "_amd64.go"} {
+		var want map[string]string
+		for _, variant := range []string{"noinline", "default", "nosplit"} {
+			file := "callees_" + variant + suffix
+			funcs := callees(t, file, directives[variant])
+			if want == nil {
+				want = funcs
+				continue
+			}
+			for name, src := range want {
+				if got, ok := funcs[name]; !ok {
+					t.Errorf("%s: %s missing", file, name)
+				} else if got != src {
+					t.Errorf("%s: %s is\n%s\nwant\n%s", file, name, got, src)
+				}
+			}
+			for name := range funcs {
+				if _, ok := want[name]; !ok {
+					t.Errorf("%s: %s not in the other variants", file, name)
+				}
+			}
+		}
+	}
+}
+
+// callees returns the functions of file by name, printed without their
+// comments, and checks that their directives are exactly want.
+func callees(t *testing.T, file string, want []string) map[string]string {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := make(map[string]string)
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := fn.Name.Name
+		if fn.Recv != nil {
+			var b bytes.Buffer
+			printer.Fprint(&b, fset, fn.Recv.List[0].Type)
+			name = "(" + b.String() + ")." + name
+		}
+		var got []string
+		if fn.Doc != nil {
+			for _, c := range fn.Doc.List {
+				if strings.HasPrefix(c.Text, "//go:") {
+					got = append(got, c.Text)
+				}
+			}
+		}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s: %s has directives %q, want %q", file, name, got, want)
+		}
+		fn.Doc = nil
+		var b bytes.Buffer
+		printer.Fprint(&b, fset, fn)
+		funcs[name] = b.String()
+	}
+	return funcs
+}
@@ -0,0 +1,70 @@
+package scenario
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// The conv scenarios convert a buffer of each size once per operation.
+// string(b) and []byte(s) copy the bytes into a new allocation unless the
+// compiler sees that the result is only read in place: as a map key in a
+// lookup, in a comparison, or, for []byte(s), when the slice is neither
+// written to nor escapes. Converting a slice to an array copies it as well;
+// converting it to a pointer to an array does not.
+func init() {
+	conv[[4 << 10]byte, *[4 << 10]byte]()
+	conv[[256 << 10]byte, *[256 << 10]byte]() // the size of BigStruct.Buf
+}
+
+var (
+	convString string
+	convBytes  []byte
+	convMap    = map[string]int{}
+	convSink   int
+	convPtr    unsafe.Pointer
+)
+
+// conv registers the scenarios for the array type A. P must be *A: Go
+// cannot convert a slice to a pointer to a type parameter, so the pointer
+// type is a parameter of its own, checked here.
+func conv[A ~[4 << 10]byte | ~[256 << 10]byte, P ~*[4 << 10]byte | ~*[256 << 10]byte]() {
+	if reflect.TypeFor[P]().Elem() != reflect.TypeFor[A]() {
+		panic("scenario: conv of " + reflect.TypeFor[A]().String() + " with " + reflect.TypeFor[P]().String() + ", want a pointer to it")
+	}
+	size := int64(unsafe.Sizeof(*new(A)))
+	buf := make([]byte, size)
+	str := string(buf)
+	other := "x" + str[1:]
+	Register(
+		&Scenario{Group: "conv", Variant: "string", Size: size, Op: func() func() {
+			return func() { convString = string(buf) }
+		}},
+		&Scenario{Group: "conv", Variant: "string-lookup", Size: size, Op: func() func() {
+			return func() { convSink = convMap[string(buf)] }
+		}},
+		&Scenario{Group: "conv", Variant: "string-compare", Size: size, Op: func() func() {
+			return func() {
+				if string(buf) == other {
+					convSink++
+				}
+			}
+		}},
+		&Scenario{Group: "conv", Variant: "bytes", Size: size, Op: func() func() {
+			return func() { convBytes = []byte(str) }
+		}},
+		&Scenario{Group: "conv", Variant: "bytes-read", Size: size, Op: func() func() {
+			return func() {
+				b := []byte(str)
+				convSink += int(b[len(b)-1])
+			}
+		}},
+		&Scenario{Group: "conv", Variant: "array", Size: size, Op: func() func() {
+			dst := new(A)
+			convPtr = unsafe.Pointer(dst)
+			return func() { *dst = A(buf) }
+		}},
+		&Scenario{Group: "conv", Variant: "array-pointer", Size: size, Op: func() func() {
+			return func() { convPtr = unsafe.Pointer(P(buf)) }
+		}},
+	)
+}
@@ -0,0 +1,136 @@
+package scenario
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// The goroutine scenarios start a goroutine per operation and wait for it
+// to finish. A large by-value argument is copied into a closure by the go
+// statement and then onto the new goroutine's stack, which starts small and
+// has to grow to hold it; a pointer or a captured variable is not copied.
+// Besides the time, every scenario reports the stack its goroutines grow to.
+func init() {
+	spawn[[8]byte]()
+	spawn[[64]byte]()
+	spawn[[512]byte]()
+	spawn[[4 << 10]byte]()
+	spawn[[32 << 10]byte]()
+	spawn[[128 << 10]byte]()
+	spawn[[256 << 10]byte]()
+	spawn[[512 << 10]byte]()
+}
+
+// stackSamples is the number of goroutines held alive to measure the stack
+// of one.
+const stackSamples = 16
+
+func spawn[T any]() {
+	var v T
+	size := int64(unsafe.Sizeof(v))
+	// start starts the goroutine of an operation, which calls done when it
+	// is finished. The held goroutines of the stack measurement are started
+	// the same way, with the same arguments, and wait for release instead.
+	type variant struct {
+		start func(wg *sync.WaitGroup)
+		hold  func(ready *sync.WaitGroup, release chan struct{})
+	}
+	variants := map[string]variant{
+		"value": {
+			start: func(wg *sync.WaitGroup) {
+				go runValue(v, wg)
+			},
+			hold: func(ready *sync.WaitGroup, release chan struct{}) {
+				go holdValue(v, ready, release)
+			},
+		},
+		"pointer": {
+			start: func(wg *sync.WaitGroup) {
+				go runPointer(&v, wg)
+			},
+			hold: func(ready *sync.WaitGroup, release chan struct{}) {
+				go holdPointer(&v, ready, release)
+			},
+		},
+		"closure": {
+			start: func(wg *sync.WaitGroup) {
+				go func() {
+					touch(&v)
+					wg.Done()
+				}()
+			},
+			hold: func(ready *sync.WaitGroup, release chan struct{}) {
+				go func() {
+					touch(&v)
+					ready.Done()
+					<-release
+				}()
+			},
+		},
+	}
+	for name, vr := range variants {
+		op := func() func() {
+			var wg sync.WaitGroup
+			return func() {
+				wg.Add(1)
+				vr.start(&wg)
+				wg.Wait()
+			}
+		}
+		Register(&Scenario{Group: "goroutine", Variant: name, Size: size, Op: op,
+			Bench: func(b *testing.B) {
+				f := op()
+				b.ResetTimer()
+				for range b.N {
+					f()
+				}
+				b.StopTimer()
+				b.ReportMetric(float64(goroutineStack(vr.hold)), "stack-B/op")
+			},
+		})
+	}
+}
+
+// goroutineStack returns the stack memory one goroutine started by hold
+// uses: the growth of the runtime's stack memory while stackSamples of them
+// wait, divided among them. The runtime does not count the times a stack
+// grows, but a stack that had to grow to hold a large argument keeps its
+// final size until the goroutine exits. Stacks the runtime keeps for reuse
+// are counted as in use, so small stacks, which come from those caches,
+// may not show up.
+func goroutineStack(hold func(ready *sync.WaitGroup, release chan struct{})) int64 {
+	// A collection returns the stacks freed so far to the heap.
+	runtime.GC()
+	before := stackBytes()
+	var ready sync.WaitGroup
+	release := make(chan struct{})
+	ready.Add(stackSamples)
+	for range stackSamples {
+		hold(&ready, release)
+	}
+	ready.Wait()
+	after := stackBytes()
+	close(release)
+	return max(after-before, 0) / stackSamples
+}
+
+func stackBytes() int64 {
+	s := []metrics.Sample{{Name: "/memory/classes/heap/stacks:bytes"}}
+	metrics.Read(s)
+	return int64(s[0].Value.Uint64())
+}
+
+//go:noinline
+func holdValue[T any](v T, ready *sync.WaitGroup, release chan struct{}) {
+	ready.Done()
+	<-release
+}
+
+//go:noinline
+func holdPointer[T any](v *T, ready *sync.WaitGroup, release chan struct{}) {
+	ready.Done()
+	<-release
+}
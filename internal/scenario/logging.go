@@ -0,0 +1,91 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"unsafe"
+)
+
+// The logging scenarios pass a value, or a pointer to it, to fmt, log and
+// log/slog once per operation. Every argument is boxed into an any at the
+// call site, which copies a value to the heap whether or not the message is
+// written: "disabled" logs below the logger's level, or to io.Discard for
+// log. The verb is %T and the slog handler drops the record, so none of the
+// scenarios spends time formatting the value itself.
+func init() {
+	logging[[64]byte]()
+	logging[[4 << 10]byte]()
+	logging[[256 << 10]byte]() // the size of BigStruct
+}
+
+// countWriter counts and drops what is written to it.
+type countWriter struct{ n int }
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+// dropHandler is an enabled slog handler that reads the attributes of each
+// record and drops it.
+type dropHandler struct{ level slog.Level }
+
+func (h dropHandler) Enabled(_ context.Context, l slog.Level) bool { return l >= h.level }
+
+func (h dropHandler) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		logSink = a.Value.Any()
+		return true
+	})
+	return nil
+}
+
+func (h dropHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h dropHandler) WithGroup(string) slog.Handler { return h }
+
+var logSink any
+
+func logging[T any]() {
+	var v T
+	size := int64(unsafe.Sizeof(v))
+	w := &countWriter{}
+	logger := log.New(w, "", 0)
+	discard := log.New(io.Discard, "", 0)
+	slogger := slog.New(dropHandler{level: slog.LevelInfo})
+	Register(
+		&Scenario{Group: "fmt", Variant: "value", Size: size, Op: func() func() {
+			return func() { fmt.Fprintf(w, "%T", v) }
+		}},
+		&Scenario{Group: "fmt", Variant: "pointer", Size: size, Op: func() func() {
+			return func() { fmt.Fprintf(w, "%T", &v) }
+		}},
+		&Scenario{Group: "log", Variant: "value", Size: size, Op: func() func() {
+			return func() { logger.Printf("%T", v) }
+		}},
+		&Scenario{Group: "log", Variant: "pointer", Size: size, Op: func() func() {
+			return func() { logger.Printf("%T", &v) }
+		}},
+		&Scenario{Group: "log", Variant: "disabled-value", Size: size, Op: func() func() {
+			return func() { discard.Printf("%T", v) }
+		}},
+		&Scenario{Group: "log", Variant: "disabled-pointer", Size: size, Op: func() func() {
+			return func() { discard.Printf("%T", &v) }
+		}},
+		&Scenario{Group: "slog", Variant: "value", Size: size, Op: func() func() {
+			return func() { slogger.Info("msg", "v", v) }
+		}},
+		&Scenario{Group: "slog", Variant: "pointer", Size: size, Op: func() func() {
+			return func() { slogger.Info("msg", "v", &v) }
+		}},
+		&Scenario{Group: "slog", Variant: "disabled-value", Size: size, Op: func() func() {
+			return func() { slogger.Debug("msg", "v", v) }
+		}},
+		&Scenario{Group: "slog", Variant: "disabled-pointer", Size: size, Op: func() func() {
+			return func() { slogger.Debug("msg", "v", &v) }
+		}},
+	)
+}
@@ -0,0 +1,60 @@
+package scenario
+
+import "unsafe"
+
+// The loopvar scenarios walk loopLen elements and hand each to a function
+// that is not inlined. Since Go 1.22, which this module declares, every
+// iteration has its own loop variables, so keeping the address of the range
+// variable ("range-addr") or of a variable declared by a three-clause loop
+// ("for-addr") moves a fresh copy of it to the heap on every iteration.
+// Keeping the address of the element itself ("index-addr") allocates
+// nothing, and passing the variable by value ("range-value") copies it onto
+// the stack instead.
+func init() {
+	loopvar[[64]byte]()
+	loopvar[[1 << 10]byte]()
+	loopvar[[8 << 10]byte]()
+}
+
+const loopLen = 16
+
+var loopKept unsafe.Pointer
+
+func loopvar[T any]() {
+	size := int64(unsafe.Sizeof(*new(T)))
+	Register(
+		&Scenario{Group: "loopvar", Variant: "range-addr", Size: size, Op: func() func() {
+			s := make([]T, loopLen)
+			return func() {
+				for _, v := range s {
+					keepAddr(&v)
+				}
+			}
+		}},
+		&Scenario{Group: "loopvar", Variant: "for-addr", Size: size, Op: func() func() {
+			s := make([]T, loopLen)
+			return func() {
+				for i, v := 0, s[0]; i < len(s); i++ {
+					v = s[i]
+					keepAddr(&v)
+				}
+			}
+		}},
+		&Scenario{Group: "loopvar", Variant: "index-addr", Size: size, Op: func() func() {
+			s := make([]T, loopLen)
+			return func() {
+				for i := range s {
+					keepAddr(&s[i])
+				}
+			}
+		}},
+		&Scenario{Group: "loopvar", Variant: "range-value", Size: size, Op: func() func() {
+			s := make([]T, loopLen)
+			return func() {
+				for _, v := range s {
+					useValue(v)
+				}
+			}
+		}},
+	)
+}
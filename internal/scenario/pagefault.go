@@ -0,0 +1,81 @@
+package scenario
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// The pagefault scenarios write to every page of a fresh value, the first
+// touch that makes the kernel back it with memory. A new value on the heap
+// usually lands on memory the runtime already faulted in and reuses; a
+// fresh mapping, which is what the heap does when it grows, faults on every
+// page unless transparent huge pages back it; a new goroutine's stack comes
+// from the runtime's stack pools. "reuse" touches the same value every time.
+// Every scenario reports the minor and major page faults per operation.
+func init() {
+	pagefault[[256 << 10]byte]() // the size of BigStruct
+	pagefault[[4 << 20]byte]()   // two 2MB huge pages
+}
+
+var pageSink unsafe.Pointer
+
+// touchPages writes a byte to every page of the size bytes at p.
+func touchPages(p unsafe.Pointer, size uintptr) {
+	page := uintptr(os.Getpagesize())
+	for off := uintptr(0); off < size; off += page {
+		*(*byte)(unsafe.Add(p, off)) = 1
+	}
+}
+
+func pagefault[T any]() {
+	var reused T
+	size := unsafe.Sizeof(reused)
+	variants := map[string]func() func(){
+		"reuse": func() func() {
+			return func() { touchPages(unsafe.Pointer(&reused), size) }
+		},
+		"heap": func() func() {
+			return func() {
+				p := new(T)
+				touchPages(unsafe.Pointer(p), size)
+				pageSink = unsafe.Pointer(p)
+			}
+		},
+		"stack": func() func() {
+			var wg sync.WaitGroup
+			return func() {
+				wg.Add(1)
+				go touchValue(reused, &wg)
+				wg.Wait()
+			}
+		},
+	}
+	if mmapSupported {
+		variants["mmap"] = func() func() {
+			return func() {
+				mem, unmap := freshMapping(size)
+				touchPages(unsafe.Pointer(unsafe.SliceData(mem)), size)
+				unmap()
+			}
+		}
+	}
+	for variant, op := range variants {
+		Register(&Scenario{Group: "pagefault", Variant: variant, Size: int64(size), Op: op, Bench: func(b *testing.B) {
+			f := op()
+			minor0, major0, ok := pageFaults()
+			b.ResetTimer()
+			for range b.N {
+				f()
+			}
+			b.StopTimer()
+			if !ok {
+				return
+			}
+			minor1, major1, _ := pageFaults()
+			b.ReportMetric(float64(minor1-minor0)/float64(b.N), "minflt/op")
+			b.ReportMetric(float64(major1-major0)/float64(b.N), "majflt/op")
+		}})
+	}
+}
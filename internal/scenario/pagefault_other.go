@@ -0,0 +1,14 @@
+//go:build !unix
+
+package scenario
+
+const mmapSupported = false
+
+// pageFaults reports that page faults are only counted on Unix systems.
+func pageFaults() (minor, major int64, ok bool) {
+	return 0, 0, false
+}
+
+func freshMapping(size uintptr) ([]byte, func()) {
+	panic("scenario: fresh mappings need mmap")
+}
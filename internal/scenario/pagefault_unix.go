@@ -0,0 +1,25 @@
+//go:build unix
+
+package scenario
+
+import "syscall"
+
+const mmapSupported = true
+
+// pageFaults returns the minor and major page faults of the process so far.
+func pageFaults() (minor, major int64, ok bool) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, 0, false
+	}
+	return int64(ru.Minflt), int64(ru.Majflt), true
+}
+
+// freshMapping maps size bytes of anonymous memory that no one has touched.
+func freshMapping(size uintptr) ([]byte, func()) {
+	mem, err := syscall.Mmap(-1, 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		panic("scenario: mmap: " + err.Error())
+	}
+	return mem, func() { syscall.Munmap(mem) }
+}
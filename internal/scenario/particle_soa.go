@@ -0,0 +1,117 @@
+// Code generated by "soa -type particle,body"; DO NOT EDIT.
+
+package scenario
+
+// particleSoA holds a sequence of particle records as one slice per field.
+type particleSoA struct {
+	X  []float64
+	Y  []float64
+	VX []float64
+	VY []float64
+}
+
+// Len returns the number of records.
+func (s *particleSoA) Len() int { return len(s.X) }
+
+// At returns record i.
+func (s *particleSoA) At(i int) particle {
+	return particle{
+		X:  s.X[i],
+		Y:  s.Y[i],
+		VX: s.VX[i],
+		VY: s.VY[i],
+	}
+}
+
+// Set replaces record i with *v.
+func (s *particleSoA) Set(i int, v *particle) {
+	s.X[i] = v.X
+	s.Y[i] = v.Y
+	s.VX[i] = v.VX
+	s.VY[i] = v.VY
+}
+
+// Append adds the records of list to the end.
+func (s *particleSoA) Append(list ...particle) {
+	for i := range list {
+		v := &list[i]
+		s.X = append(s.X, v.X)
+		s.Y = append(s.Y, v.Y)
+		s.VX = append(s.VX, v.VX)
+		s.VY = append(s.VY, v.VY)
+	}
+}
+
+// AoS returns the records as a slice of particle.
+func (s *particleSoA) AoS() []particle {
+	list := make([]particle, s.Len())
+	for i := range list {
+		list[i].X = s.X[i]
+		list[i].Y = s.Y[i]
+		list[i].VX = s.VX[i]
+		list[i].VY = s.VY[i]
+	}
+	return list
+}
+
+// bodySoA holds a sequence of body records as one slice per field.
+type bodySoA struct {
+	X       []float64
+	Y       []float64
+	VX      []float64
+	VY      []float64
+	Mass    []float64
+	Payload [][216]byte
+}
+
+// Len returns the number of records.
+func (s *bodySoA) Len() int { return len(s.X) }
+
+// At returns record i.
+func (s *bodySoA) At(i int) body {
+	return body{
+		X:       s.X[i],
+		Y:       s.Y[i],
+		VX:      s.VX[i],
+		VY:      s.VY[i],
+		Mass:    s.Mass[i],
+		Payload: s.Payload[i],
+	}
+}
+
+// Set replaces record i with *v.
+func (s *bodySoA) Set(i int, v *body) {
+	s.X[i] = v.X
+	s.Y[i] = v.Y
+	s.VX[i] = v.VX
+	s.VY[i] = v.VY
+	s.Mass[i] = v.Mass
+	s.Payload[i] = v.Payload
+}
+
+// Append adds the records of list to the end.
+func (s *bodySoA) Append(list ...body) {
+	for i := range list {
+		v := &list[i]
+		s.X = append(s.X, v.X)
+		s.Y = append(s.Y, v.Y)
+		s.VX = append(s.VX, v.VX)
+		s.VY = append(s.VY, v.VY)
+		s.Mass = append(s.Mass, v.Mass)
+		s.Payload = append(s.Payload, v.Payload)
+	}
+}
+
+// AoS returns the records as a slice of body.
+func (s *bodySoA) AoS() []body {
+	list := make([]body, s.Len())
+	for i := range list {
+		list[i].X = s.X[i]
+		list[i].Y = s.Y[i]
+		list[i].VX = s.VX[i]
+		list[i].VY = s.VY[i]
+		list[i].Mass = s.Mass[i]
+		list[i].Payload = s.Payload[i]
+	}
+	return list
+}
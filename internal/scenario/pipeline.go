@@ -0,0 +1,201 @@
+package scenario
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/stats"
+)
+
+// The pipeline scenarios move messages from a producer through a pool of
+// workers to an aggregator, one message per operation. Messages travel by
+// value through the channels, by pointer to a freshly allocated message, or
+// as an index into a ring buffer shared by all stages. Sizes stay below the
+// 64KB limit on channel element types.
+func init() {
+	pipeline[[64]byte]()
+	pipeline[[1 << 10]byte]()
+	pipeline[[8 << 10]byte]()
+	pipeline[[32 << 10]byte]()
+}
+
+const (
+	pipelineWorkers = 4
+	pipelineBuffer  = 64
+	pipelineRing    = 256
+)
+
+type message[T any] struct {
+	seq     int
+	sent    time.Time
+	payload T
+}
+
+type processed struct {
+	seq  int
+	sent time.Time
+	slot int
+}
+
+func pipeline[T any]() {
+	size := int64(unsafe.Sizeof(message[T]{}))
+	for variant, stages := range map[string]func() pipelineStages{
+		"value":   valueStages[T],
+		"pointer": pointerStages[T],
+		"index":   indexStages[T],
+	} {
+		Register(&Scenario{Group: "pipeline", Variant: variant, Size: size,
+			Op: func() func() {
+				return stages().op()
+			},
+			Bench: func(b *testing.B) {
+				runPipeline(b, stages())
+			},
+		})
+	}
+}
+
+// pipelineStages is one way of passing messages through the pipeline: send
+// puts a message in, stop closes the pipeline after the last one, and
+// worker processes messages until then. done, if set, is called by the
+// aggregator for every message.
+type pipelineStages struct {
+	send   func(seq int)
+	stop   func()
+	worker func(out chan<- processed)
+	done   func(processed)
+}
+
+func valueStages[T any]() pipelineStages {
+	in := make(chan message[T], pipelineBuffer)
+	return pipelineStages{
+		send: func(seq int) {
+			in <- message[T]{seq: seq, sent: time.Now()}
+		},
+		stop: func() { close(in) },
+		worker: func(out chan<- processed) {
+			for m := range in {
+				work(&m)
+				out <- processed{seq: m.seq, sent: m.sent}
+			}
+		},
+	}
+}
+
+func pointerStages[T any]() pipelineStages {
+	in := make(chan *message[T], pipelineBuffer)
+	return pipelineStages{
+		send: func(seq int) {
+			in <- &message[T]{seq: seq, sent: time.Now()}
+		},
+		stop: func() { close(in) },
+		worker: func(out chan<- processed) {
+			for m := range in {
+				work(m)
+				out <- processed{seq: m.seq, sent: m.sent}
+			}
+		},
+	}
+}
+
+func indexStages[T any]() pipelineStages {
+	ring := make([]message[T], pipelineRing)
+	free := make(chan int, pipelineRing)
+	for i := range pipelineRing {
+		free <- i
+	}
+	in := make(chan int, pipelineBuffer)
+	return pipelineStages{
+		send: func(seq int) {
+			slot := <-free
+			ring[slot].seq, ring[slot].sent = seq, time.Now()
+			in <- slot
+		},
+		stop: func() { close(in) },
+		worker: func(out chan<- processed) {
+			for slot := range in {
+				m := &ring[slot]
+				work(m)
+				out <- processed{seq: m.seq, sent: m.sent, slot: slot}
+			}
+		},
+		done: func(p processed) {
+			free <- p.slot
+		},
+	}
+}
+
+// start runs pipelineWorkers copies of the worker and returns the channel
+// of processed messages, closed once the workers are done.
+func (s pipelineStages) start() <-chan processed {
+	out := make(chan processed, pipelineBuffer)
+	var wg sync.WaitGroup
+	for range pipelineWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.worker(out)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// op returns an operation that sends one message through an otherwise idle
+// pipeline and waits for the aggregator to receive it. The workers keep
+// running between operations and are stopped once the operation is
+// garbage.
+func (s pipelineStages) op() func() {
+	out := s.start()
+	stopper := &struct{ stop func() }{s.stop}
+	runtime.SetFinalizer(stopper, func(st *struct{ stop func() }) { st.stop() })
+	seq := 0
+	return func() {
+		s.send(seq)
+		seq++
+		p := <-out
+		if s.done != nil {
+			s.done(p)
+		}
+		runtime.KeepAlive(stopper)
+	}
+}
+
+// runPipeline sends b.N messages through the pipeline, then reports the
+// end-to-end latency percentiles, the throughput and the garbage
+// collector's cost.
+func runPipeline(b *testing.B, s pipelineStages) {
+	latencies := make([]float64, 0, b.N)
+	report := gcCost()
+	b.ResetTimer()
+	start := time.Now()
+
+	out := s.start()
+	go func() {
+		for i := range b.N {
+			s.send(i)
+		}
+		s.stop()
+	}()
+	for p := range out {
+		latencies = append(latencies, float64(time.Since(p.sent)))
+		if s.done != nil {
+			s.done(p)
+		}
+	}
+
+	elapsed := time.Since(start)
+	b.StopTimer()
+	report(b)
+	slices.Sort(latencies)
+	b.ReportMetric(stats.Percentile(latencies, 50), "p50-ns")
+	b.ReportMetric(stats.Percentile(latencies, 99), "p99-ns")
+	b.ReportMetric(float64(b.N)/elapsed.Seconds(), "msgs/s")
+}
@@ -0,0 +1,161 @@
+// Package scenario is the registry of benchmark scenarios shared by the
+// tools in this module. Every scenario is an ordinary benchmark function, so
+// it runs both under "go test -bench" (see BenchmarkScenarios) and from the
+// commands through Run.
+package scenario
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+// A Scenario is one benchmark in a comparison. Scenarios of the same Group
+// and Size differ only in Variant, e.g. "value" and "pointer".
+type Scenario struct {
+	Group   string
+	Variant string
+	Size    int64 // bytes passed or copied per operation
+	Bench   func(b *testing.B)
+
+	// Op, if set, returns a single operation of the scenario, for the
+	// measurements that time operations one batch at a time. When Bench is
+	// nil, Register derives it from Op.
+	Op func() func()
+}
+
+// Name is the unique name of the scenario, "group/variant/size".
+func (s *Scenario) Name() string {
+	return fmt.Sprintf("%s/%s/%d", s.Group, s.Variant, s.Size)
+}
+
+var registry = make(map[string]*Scenario)
+
+// Register adds scenarios to the registry. It panics on duplicate names.
+func Register(list ...*Scenario) {
+	for _, s := range list {
+		name := s.Name()
+		if _, dup := registry[name]; dup {
+			panic("scenario: duplicate scenario " + name)
+		}
+		if s.Bench == nil {
+			if s.Op == nil {
+				panic("scenario: scenario " + name + " has neither Bench nor Op")
+			}
+			op := s.Op
+			s.Bench = func(b *testing.B) {
+				f := op()
+				b.ResetTimer()
+				for range b.N {
+					f()
+				}
+			}
+		}
+		registry[name] = s
+	}
+}
+
+// All returns every registered scenario ordered by group, size and variant.
+func All() []*Scenario {
+	list := make([]*Scenario, 0, len(registry))
+	for _, s := range registry {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Size != b.Size {
+			return a.Size < b.Size
+		}
+		return a.Variant < b.Variant
+	})
+	return list
+}
+
+// Match returns the scenarios whose name matches the regular expression.
+func Match(expr string) ([]*Scenario, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Scenario
+	for _, s := range All() {
+		if re.MatchString(s.Name()) {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+// Lookup returns the scenario with the given name, or nil.
+func Lookup(name string) *Scenario {
+	return registry[name]
+}
+
+// Group returns the scenarios of the named group.
+func Group(name string) []*Scenario {
+	var list []*Scenario
+	for _, s := range All() {
+		if s.Group == name {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// Result is the outcome of running a scenario once.
+type Result struct {
+	Name        string             `json:"name"`
+	N           int                `json:"n"`
+	NsPerOp     float64            `json:"ns_per_op"`
+	AllocsPerOp int64              `json:"allocs_per_op"`
+	BytesPerOp  int64              `json:"bytes_per_op"`
+	Extra       map[string]float64 `json:"extra,omitempty"`
+}
+
+// Run benchmarks s once with the testing package. Commands that want to
+// change the benchmark time must call testing.Init before flag.Parse and
+// then SetBenchtime.
+func Run(s *Scenario) Result {
+	r := testing.Benchmark(s.Bench)
+	res := Result{
+		Name:        s.Name(),
+		N:           r.N,
+		AllocsPerOp: r.AllocsPerOp(),
+		BytesPerOp:  r.AllocedBytesPerOp(),
+		Extra:       r.Extra,
+	}
+	if r.N > 0 {
+		res.NsPerOp = float64(r.T.Nanoseconds()) / float64(r.N)
+	}
+	return res
+}
+
+// SetBenchtime sets the duration (e.g. "200ms") or iteration count
+// (e.g. "1000x") used by Run.
+func SetBenchtime(d string) error {
+	if flag.Lookup("test.benchtime") == nil {
+		return fmt.Errorf("scenario: testing.Init was not called")
+	}
+	return flag.Set("test.benchtime", d)
+}
+
+// gcCost reads the garbage collector's statistics and returns a function
+// that reports the collections and the pause time since, per operation of
+// b, as "gcs/op" and "gc-pause-ns/op". Benchmarks call it before
+// b.ResetTimer and the function after b.StopTimer.
+func gcCost() func(b *testing.B) {
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	return func(b *testing.B) {
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gcs/op")
+		b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+	}
+}
@@ -0,0 +1,65 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+)
+
+func BenchmarkScenarios(b *testing.B) {
+	for _, s := range All() {
+		b.Run(s.Name(), s.Bench)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	all := All()
+	if len(all) == 0 {
+		t.Fatal("no scenarios registered")
+	}
+	for _, s := range all {
+		if s.Group == "" || s.Variant == "" || s.Bench == nil {
+			t.Errorf("incomplete scenario %q", s.Name())
+		}
+		if strings.Count(s.Name(), "/") != 2 {
+			t.Errorf("scenario name %q has extra separators", s.Name())
+		}
+	}
+	sweep := Group("sweep")
+	if len(sweep)%2 != 0 {
+		t.Errorf("sweep has %d scenarios, want value/pointer pairs", len(sweep))
+	}
+	m, err := Match("^sweep/value/")
+	if err != nil || len(m) != len(sweep)/2 {
+		t.Errorf("Match(^sweep/value/) = %d, %v; want %d", len(m), err, len(sweep)/2)
+	}
+	if s := Lookup(sweep[0].Name()); s != sweep[0] {
+		t.Errorf("Lookup(%s) = %v", sweep[0].Name(), s)
+	}
+	if s := Lookup("sweep/value/.*"); s != nil {
+		t.Errorf("Lookup(sweep/value/.*) = %s, want nil", s.Name())
+	}
+}
+
+// TestConvAllocs checks which conversions the compiler performs in place.
+func TestConvAllocs(t *testing.T) {
+	want := map[string]float64{
+		"string": 1, "string-lookup": 0, "string-compare": 0,
+		"bytes": 1, "bytes-read": 0,
+		"array": 0, "array-pointer": 0,
+	}
+	for _, s := range Group("conv") {
+		op := s.Op()
+		if got := testing.AllocsPerRun(10, op); got != want[s.Variant] {
+			t.Errorf("%s: %v allocations per operation, want %v", s.Name(), got, want[s.Variant])
+		}
+	}
+}
+
+func TestConvMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("conv registered a 4KB array with a pointer to a 256KB one")
+		}
+	}()
+	conv[[4 << 10]byte, *[256 << 10]byte]()
+}
@@ -0,0 +1,162 @@
+package scenario
+
+import "unsafe"
+
+//go:generate go run github.com/rohanchauhan02/valuevspointer/cmd/soa -type particle,body
+
+// The layout scenarios keep layoutRecords records as an array of structs or,
+// through the generated particleSoA and bodySoA, as a struct of arrays. One
+// operation is a pass over every record: "soa-scan" sums one field, and
+// "soa-update" moves every record by its velocity through a function that is
+// not inlined, taking the record by value, by pointer, or as an index into
+// the struct of arrays. The particle is small and every field is updated;
+// the body carries a payload neither pass reads.
+func init() {
+	layoutScenarios(int64(unsafe.Sizeof(particle{})), [3]func() func(){
+		func() func() {
+			list := make([]particle, layoutRecords)
+			return func() {
+				var sum float64
+				for _, p := range list {
+					sum += p.X
+				}
+				layoutSink = sum
+			}
+		},
+		func() func() {
+			list := make([]particle, layoutRecords)
+			return func() {
+				var sum float64
+				for i := range list {
+					sum += (&list[i]).X
+				}
+				layoutSink = sum
+			}
+		},
+		func() func() {
+			var s particleSoA
+			s.Append(make([]particle, layoutRecords)...)
+			return func() {
+				var sum float64
+				for _, x := range s.X {
+					sum += x
+				}
+				layoutSink = sum
+			}
+		},
+	}, [3]func() func(){
+		func() func() {
+			list := make([]particle, layoutRecords)
+			return func() {
+				for i := range list {
+					list[i] = moveParticle(list[i])
+				}
+			}
+		},
+		func() func() {
+			list := make([]particle, layoutRecords)
+			return func() {
+				for i := range list {
+					moveParticleAt(&list[i])
+				}
+			}
+		},
+		func() func() {
+			var s particleSoA
+			s.Append(make([]particle, layoutRecords)...)
+			return func() {
+				for i := range s.Len() {
+					s.move(i)
+				}
+			}
+		},
+	})
+
+	layoutScenarios(int64(unsafe.Sizeof(body{})), [3]func() func(){
+		func() func() {
+			list := make([]body, layoutRecords)
+			return func() {
+				var sum float64
+				for _, b := range list {
+					sum += b.X
+				}
+				layoutSink = sum
+			}
+		},
+		func() func() {
+			list := make([]body, layoutRecords)
+			return func() {
+				var sum float64
+				for i := range list {
+					sum += (&list[i]).X
+				}
+				layoutSink = sum
+			}
+		},
+		func() func() {
+			var s bodySoA
+			s.Append(make([]body, layoutRecords)...)
+			return func() {
+				var sum float64
+				for _, x := range s.X {
+					sum += x
+				}
+				layoutSink = sum
+			}
+		},
+	}, [3]func() func(){
+		func() func() {
+			list := make([]body, layoutRecords)
+			return func() {
+				for i := range list {
+					list[i] = moveBody(list[i])
+				}
+			}
+		},
+		func() func() {
+			list := make([]body, layoutRecords)
+			return func() {
+				for i := range list {
+					moveBodyAt(&list[i])
+				}
+			}
+		},
+		func() func() {
+			var s bodySoA
+			s.Append(make([]body, layoutRecords)...)
+			return func() {
+				for i := range s.Len() {
+					s.move(i)
+				}
+			}
+		},
+	})
+}
+
+const layoutRecords = 4096
+
+var layoutSink float64
+
+type particle struct {
+	X, Y   float64
+	VX, VY float64
+}
+
+type body struct {
+	X, Y    float64
+	VX, VY  float64
+	Mass    float64
+	Payload [216]byte
+}
+
+// layoutVariants name the layouts in the order layoutScenarios takes them.
+var layoutVariants = [3]string{"aos-value", "aos-pointer", "soa"}
+
+func layoutScenarios(size int64, scan, update [3]func() func()) {
+	for i, variant := range layoutVariants {
+		Register(
+			&Scenario{Group: "soa-scan", Variant: variant, Size: size, Op: scan[i]},
+			&Scenario{Group: "soa-update", Variant: variant, Size: size, Op: update[i]},
+		)
+	}
+}
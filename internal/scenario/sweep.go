@@ -0,0 +1,39 @@
+package scenario
+
+import "unsafe"
+
+// The sweep passes byte arrays of doubling sizes to a function that is not
+// inlined, so the copy the Readme shows for BigStruct really happens even
+// with optimizations on. Built with the directive_default tag the function
+// may be inlined instead; see callees_noinline.go.
+func init() {
+	sweep[[8]byte]()
+	sweep[[16]byte]()
+	sweep[[32]byte]()
+	sweep[[64]byte]()
+	sweep[[128]byte]()
+	sweep[[256]byte]()
+	sweep[[512]byte]()
+	sweep[[1 << 10]byte]()
+	sweep[[2 << 10]byte]()
+	sweep[[4 << 10]byte]()
+	sweep[[8 << 10]byte]()
+	sweep[[16 << 10]byte]()
+	sweep[[32 << 10]byte]()
+	sweep[[64 << 10]byte]()
+	sweep[[128 << 10]byte]()
+	sweep[[1 << 18]byte]()
+}
+
+func sweep[T any]() {
+	var v T
+	size := int64(unsafe.Sizeof(v))
+	Register(
+		&Scenario{Group: "sweep", Variant: "value", Size: size, Op: func() func() {
+			return func() { byValue(v) }
+		}},
+		&Scenario{Group: "sweep", Variant: "pointer", Size: size, Op: func() func() {
+			return func() { byPointer(&v) }
+		}},
+	)
+}
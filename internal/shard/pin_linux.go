@@ -0,0 +1,80 @@
+package shard
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// cpuSet is the kernel's cpu_set_t for up to 1024 CPUs.
+type cpuSet [1024 / 64]uint64
+
+// Allowed returns the CPUs the process may run on.
+func Allowed() ([]int, error) {
+	var set cpuSet
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETAFFINITY, 0, unsafe.Sizeof(set), uintptr(unsafe.Pointer(&set)))
+	if errno != 0 {
+		return nil, fmt.Errorf("sched_getaffinity: %v", errno)
+	}
+	var cpus []int
+	for i := range len(set) * 64 {
+		if set[i/64]&(1<<(i%64)) != 0 {
+			cpus = append(cpus, i)
+		}
+	}
+	return cpus, nil
+}
+
+// PinThread restricts the calling thread to cpus. Callers lock the
+// goroutine to its thread first.
+func PinThread(cpus ...int) error {
+	set, err := newSet(cpus)
+	if err != nil {
+		return err
+	}
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, unsafe.Sizeof(set), uintptr(unsafe.Pointer(&set)))
+	if errno != 0 {
+		return fmt.Errorf("pinning to CPUs %v: sched_setaffinity: %v", cpus, errno)
+	}
+	return nil
+}
+
+// Pin restricts every thread of the process to cpus. Threads started later
+// inherit the restriction from the thread that starts them.
+func Pin(cpus ...int) error {
+	set, err := newSet(cpus)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		tid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(tid), unsafe.Sizeof(set), uintptr(unsafe.Pointer(&set)))
+		if errno != 0 && errno != syscall.ESRCH {
+			return fmt.Errorf("pinning to CPUs %v: sched_setaffinity: %v", cpus, errno)
+		}
+	}
+	return nil
+}
+
+func newSet(cpus []int) (cpuSet, error) {
+	var set cpuSet
+	if len(cpus) == 0 {
+		return set, fmt.Errorf("cannot pin to no CPU")
+	}
+	for _, cpu := range cpus {
+		if cpu < 0 || cpu >= len(set)*64 {
+			return set, fmt.Errorf("cannot pin to CPU %d", cpu)
+		}
+		set[cpu/64] |= 1 << (cpu % 64)
+	}
+	return set, nil
+}
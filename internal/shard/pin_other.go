@@ -0,0 +1,22 @@
+//go:build !linux
+
+package shard
+
+import "errors"
+
+var errPin = errors.New("pinning shards to CPUs is only supported on Linux")
+
+// Allowed reports that CPU affinity is only read on Linux.
+func Allowed() ([]int, error) {
+	return nil, errPin
+}
+
+// Pin reports that CPU affinity is only set on Linux.
+func Pin(cpus ...int) error {
+	return errPin
+}
+
+// PinThread reports that CPU affinity is only set on Linux.
+func PinThread(cpus ...int) error {
+	return errPin
+}
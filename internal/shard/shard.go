@@ -0,0 +1,135 @@
+// Package shard splits a list of scenarios into shards run by separate
+// processes, pins those processes to CPUs, and records the environment each
+// shard ran in so the merged results can be checked for consistency.
+package shard
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/thp"
+)
+
+// Spec identifies shard Index of Count, written "index/count".
+type Spec struct {
+	Index, Count int
+}
+
+func (s Spec) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// ParseSpec parses "index/count".
+func ParseSpec(text string) (Spec, error) {
+	i, n, ok := strings.Cut(text, "/")
+	index, err1 := strconv.Atoi(i)
+	count, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil || count < 1 || index < 0 || index >= count {
+		return Spec{}, fmt.Errorf("invalid shard %q, want index/count with 0 <= index < count", text)
+	}
+	return Spec{index, count}, nil
+}
+
+// Split returns the items of shard s. Items are dealt round robin, so that
+// every shard gets a share of each group of neighbouring, similar items.
+func Split[T any](items []T, s Spec) []T {
+	var out []T
+	for i := s.Index; i < len(items); i += s.Count {
+		out = append(out, items[i])
+	}
+	return out
+}
+
+// Env is the environment a shard ran in.
+type Env struct {
+	GOOS       string `json:"goos"`
+	GOARCH     string `json:"goarch"`
+	GoVersion  string `json:"go_version"`
+	CPU        string `json:"cpu,omitempty"`
+	NumCPU     int    `json:"num_cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	GOGC       string `json:"gogc,omitempty"`
+	GODEBUG    string `json:"godebug,omitempty"`
+	THP        string `json:"thp,omitempty"`
+	// Pinned lists the CPUs the shard was pinned to, if any. It is expected
+	// to differ between shards and is left out of Compare.
+	Pinned []int `json:"pinned,omitempty"`
+}
+
+// Current returns the environment of the running process.
+func Current() Env {
+	e := Env{
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		GoVersion:  runtime.Version(),
+		CPU:        cpuModel(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		GOGC:       os.Getenv("GOGC"),
+		GODEBUG:    os.Getenv("GODEBUG"),
+	}
+	if s, err := thp.Read(); err == nil {
+		e.THP = s.Enabled.Current
+	}
+	return e
+}
+
+// cpuModel returns the processor name from /proc/cpuinfo, where there is
+// one.
+func cpuModel() string {
+	data, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(k) == "model name" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// Compare returns a description of every way in which the environments of
+// later shards differ from the first one, and of CPUs shared by pinned
+// shards.
+func Compare(envs []Env) []string {
+	if len(envs) == 0 {
+		return nil
+	}
+	var diffs []string
+	first := envs[0]
+	fields := func(e Env) []struct{ name, value string } {
+		return []struct{ name, value string }{
+			{"GOOS", e.GOOS},
+			{"GOARCH", e.GOARCH},
+			{"Go version", e.GoVersion},
+			{"CPU", e.CPU},
+			{"NumCPU", strconv.Itoa(e.NumCPU)},
+			{"GOMAXPROCS", strconv.Itoa(e.GOMAXPROCS)},
+			{"GOGC", e.GOGC},
+			{"GODEBUG", e.GODEBUG},
+			{"transparent huge pages", e.THP},
+		}
+	}
+	want := fields(first)
+	for i, e := range envs[1:] {
+		for j, f := range fields(e) {
+			if f.value != want[j].value {
+				diffs = append(diffs, fmt.Sprintf("shard %d: %s is %q, shard 0 has %q", i+1, f.name, f.value, want[j].value))
+			}
+		}
+	}
+	owner := make(map[int]int)
+	for i, e := range envs {
+		for _, cpu := range e.Pinned {
+			if j, dup := owner[cpu]; dup {
+				diffs = append(diffs, fmt.Sprintf("shards %d and %d are both pinned to CPU %d", j, i, cpu))
+			}
+			owner[cpu] = i
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,62 @@
+package shard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4, 5, 6}
+	var all []int
+	for i := range 3 {
+		part := Split(items, Spec{i, 3})
+		if want := (len(items) + 2 - i) / 3; len(part) != want {
+			t.Errorf("shard %d has %d items, want %d", i, len(part), want)
+		}
+		all = append(all, part...)
+	}
+	if len(all) != len(items) {
+		t.Errorf("shards hold %d items, want %d", len(all), len(items))
+	}
+	if got := Split(items, Spec{1, 3}); !reflect.DeepEqual(got, []int{1, 4}) {
+		t.Errorf("shard 1/3 = %v", got)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	if s, err := ParseSpec("2/4"); err != nil || s != (Spec{2, 4}) || s.String() != "2/4" {
+		t.Errorf("ParseSpec(2/4) = %v, %v", s, err)
+	}
+	for _, bad := range []string{"4/4", "-1/2", "1", "a/b", "0/0"} {
+		if _, err := ParseSpec(bad); err == nil {
+			t.Errorf("ParseSpec(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	e := Current()
+	if diffs := Compare([]Env{e, e}); len(diffs) != 0 {
+		t.Errorf("identical environments differ: %v", diffs)
+	}
+	other := e
+	other.GOMAXPROCS++
+	other.Pinned = []int{3}
+	pinned := e
+	pinned.Pinned = []int{3}
+	diffs := Compare([]Env{e, other, pinned})
+	if len(diffs) != 2 || !strings.Contains(diffs[0], "shard 1: GOMAXPROCS") || !strings.Contains(diffs[1], "CPU 3") {
+		t.Errorf("Compare = %q", diffs)
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	cpus, err := Allowed()
+	if err != nil {
+		t.Skip(err)
+	}
+	if len(cpus) == 0 {
+		t.Error("no CPUs allowed")
+	}
+}
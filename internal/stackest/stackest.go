@@ -0,0 +1,174 @@
+// Package stackest estimates the worst-case stack depth reached from each
+// entry point of a package, from the frame sizes in the compiler's assembly
+// listing and the direct calls between its functions.
+package stackest
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+// Chain is the deepest call chain from an entry point.
+type Chain struct {
+	Entry     string `json:"entry"`
+	Depth     int64  `json:"depth"`     // bytes of stack at the bottom of the chain
+	ArgBytes  int64  `json:"arg_bytes"` // of Depth, the argument areas of the calls
+	Recursive bool   `json:"recursive"` // the chain was cut at a recursive call
+	Steps     []Step `json:"steps"`     // from the entry point down
+}
+
+// Dominated reports whether most of the chain's stack is argument areas,
+// which large by-value arguments fill.
+func (c *Chain) Dominated() bool {
+	return c.ArgBytes*2 > c.Depth
+}
+
+// Step is one function of a chain. Args is the argument area its caller
+// reserves, as the listing's TEXT line gives it: the arguments passed on
+// the stack and the spill slots of those passed in registers.
+type Step struct {
+	Func   string   `json:"func"`
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Frame  int64    `json:"frame"`  // including the return address
+	Args   int64    `json:"args"`   // in the caller's frame
+	Params []string `json:"params"` // by-value parameters above the threshold
+}
+
+// Estimate returns the deepest chain from every entry point, deepest first.
+// Entry points are the functions whose symbol starts with prefix and that
+// nobody in the listing calls directly; this leaves out the instantiations
+// of other packages' generic functions that the listing also contains.
+// Calls outside the listing and indirect calls are not followed.
+func Estimate(funcs []*asm.Func, prefix, goarch string) []*Chain {
+	byName := make(map[string]*asm.Func)
+	for _, f := range funcs {
+		byName[f.Name] = f
+	}
+	called := make(map[string]bool)
+	for _, f := range funcs {
+		for _, c := range f.Calls() {
+			if c.Target() != f.Name {
+				called[c.Target()] = true
+			}
+		}
+	}
+
+	e := &estimator{byName: byName, ret: asm.ReturnAddr(goarch), memo: make(map[string]*Chain), active: make(map[string]bool)}
+	var chains []*Chain
+	for _, f := range funcs {
+		if called[f.Name] || generated(f) || !strings.HasPrefix(f.Name, prefix) {
+			continue
+		}
+		c := e.chain(f)
+		chains = append(chains, &Chain{
+			Entry:     f.Name,
+			Depth:     c.Depth,
+			ArgBytes:  c.ArgBytes,
+			Recursive: c.Recursive,
+			Steps:     c.Steps,
+		})
+	}
+	sort.SliceStable(chains, func(i, j int) bool { return chains[i].Depth > chains[j].Depth })
+	return chains
+}
+
+// generated reports whether f is emitted by the compiler rather than
+// written by hand: equality functions and wrappers.
+func generated(f *asm.Func) bool {
+	return f.Has("wrapper") || f.Has("abiwrapper") || strings.HasPrefix(f.Name, "type:")
+}
+
+type estimator struct {
+	byName map[string]*asm.Func
+	ret    int64
+	memo   map[string]*Chain
+	active map[string]bool
+}
+
+// chain returns the deepest chain starting at f, with f's own arguments
+// not counted.
+func (e *estimator) chain(f *asm.Func) *Chain {
+	if c := e.memo[f.Name]; c != nil {
+		return c
+	}
+	e.active[f.Name] = true
+	frame := f.Frame + e.ret
+	best := &Chain{Depth: frame}
+	for _, call := range f.Calls() {
+		callee := e.byName[call.Target()]
+		if callee == nil {
+			continue
+		}
+		if e.active[callee.Name] {
+			best.Recursive = true
+			continue
+		}
+		sub := e.chain(callee)
+		if d := frame + sub.Depth; d > best.Depth {
+			best = &Chain{
+				Depth:     d,
+				ArgBytes:  callee.Args + sub.ArgBytes,
+				Recursive: best.Recursive || sub.Recursive,
+				Steps:     sub.Steps,
+			}
+		} else if sub.Recursive {
+			best.Recursive = true
+		}
+	}
+	e.active[f.Name] = false
+	step := Step{Func: f.Name, File: f.File, Line: f.Line, Frame: frame, Args: f.Args}
+	best.Steps = append([]Step{step}, best.Steps...)
+	// Recursive results depend on the path that reached them; only memoize
+	// the others.
+	if !best.Recursive {
+		e.memo[f.Name] = best
+	}
+	return best
+}
+
+// Annotate lists, for every step of the chains declared in pkg, the
+// parameters it takes by value above the configured threshold.
+func Annotate(chains []*Chain, pkg *load.Package, cfg *config.Config) {
+	funcs := pkg.Funcs()
+	for _, c := range chains {
+		for i := range c.Steps {
+			s := &c.Steps[i]
+			decl := funcs[load.BaseSymbol(s.Func)]
+			if decl == nil {
+				continue
+			}
+			fn, ok := pkg.Info.Defs[decl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			sig := fn.Type().(*types.Signature)
+			vars := []*types.Var{}
+			if sig.Recv() != nil {
+				vars = append(vars, sig.Recv())
+			}
+			for i := range sig.Params().Len() {
+				vars = append(vars, sig.Params().At(i))
+			}
+			for _, v := range vars {
+				if _, ok := v.Type().Underlying().(*types.Pointer); ok {
+					continue
+				}
+				size, ok := pkg.Sizeof(v.Type())
+				if !ok {
+					continue
+				}
+				if exceeds, _ := cfg.Exceeds(pkg.Path, load.TypeName(v.Type()), size); exceeds {
+					s.Params = append(s.Params, fmt.Sprintf("%s %s (%d bytes)",
+						v.Name(), types.TypeString(v.Type(), types.RelativeTo(pkg.Types)), size))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package stackest
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/config"
+	"github.com/rohanchauhan02/valuevspointer/internal/load"
+)
+
+func TestEstimate(t *testing.T) {
+	funcs := []*asm.Func{
+		{Name: "p.Entry", Frame: 100, Instrs: []asm.Instr{
+			{Op: "CALL", Args: "p.small(SB)"},
+			{Op: "CALL", Args: "p.big(SB)"},
+			{Op: "CALL", Args: "fmt.Println(SB)"},
+		}},
+		{Name: "p.small", Frame: 10},
+		{Name: "p.big", Frame: 5000, Args: 4000, Instrs: []asm.Instr{
+			{Op: "CALL", Args: "p.rec(SB)"},
+		}},
+		{Name: "p.rec", Frame: 20, Instrs: []asm.Instr{
+			{Op: "CALL", Args: "p.rec(SB)"},
+		}},
+		{Name: "p.Other", Frame: 30, Instrs: []asm.Instr{
+			{Op: "CALL", Args: "p.small(SB)"},
+		}},
+		{Name: "type:.eq.p.T", Frame: 8},
+		{Name: "sync/atomic.(*Pointer[p.T]).Load", Frame: 8},
+	}
+	chains := Estimate(funcs, "p.", "arm64")
+	if len(chains) != 2 {
+		t.Fatalf("got %d chains, want 2 (Entry, Other)", len(chains))
+	}
+	c := chains[0]
+	var path []string
+	for _, s := range c.Steps {
+		path = append(path, s.Func)
+	}
+	if got := strings.Join(path, " "); got != "p.Entry p.big p.rec" {
+		t.Errorf("deepest path = %s", got)
+	}
+	if c.Depth != 5120 || c.ArgBytes != 4000 || !c.Recursive || !c.Dominated() {
+		t.Errorf("chain = depth %d, args %d, recursive %v, dominated %v; want 5120, 4000, true, true",
+			c.Depth, c.ArgBytes, c.Recursive, c.Dominated())
+	}
+	if o := chains[1]; o.Entry != "p.Other" || o.Depth != 40 || o.Dominated() {
+		t.Errorf("second chain = %+v", o)
+	}
+}
+
+func TestReadme(t *testing.T) {
+	// The Readme's listing, built without optimizations: main.main holds
+	// the copy of BigStruct it passes to PassByValue.
+	funcs, err := asm.Compile("../..", "-N", "-l")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := load.Dir("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chains := Estimate(funcs, pkg.SymbolPrefix(), runtime.GOARCH)
+	Annotate(chains, pkg, config.Default())
+	for _, c := range chains {
+		if c.Entry != "main.main" {
+			continue
+		}
+		if len(c.Steps) != 2 || c.ArgBytes != 1<<18 || !c.Dominated() {
+			t.Fatalf("main.main chain = %+v", c)
+		}
+		if p := c.Steps[1].Params; len(p) != 1 || !strings.HasPrefix(p[0], "obj BigStruct") {
+			t.Errorf("PassByValue params = %q", p)
+		}
+		return
+	}
+	t.Error("no chain for main.main")
+}
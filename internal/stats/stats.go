@@ -0,0 +1,81 @@
+// Package stats holds the small amount of statistics the reports need.
+package stats
+
+import "math"
+
+// Mean returns the arithmetic mean of xs, or 0 if xs is empty.
+func Mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// StdDev returns the sample standard deviation of xs.
+func StdDev(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	m := Mean(xs)
+	var ss float64
+	for _, x := range xs {
+		ss += (x - m) * (x - m)
+	}
+	return math.Sqrt(ss / float64(len(xs)-1))
+}
+
+// CI95 returns the 95% confidence interval of the mean of xs using
+// Student's t distribution. With fewer than two samples the interval is
+// the single value.
+func CI95(xs []float64) (lo, hi float64) {
+	m := Mean(xs)
+	if len(xs) < 2 {
+		return m, m
+	}
+	h := t975(len(xs)-1) * StdDev(xs) / math.Sqrt(float64(len(xs)))
+	return m - h, m + h
+}
+
+// t975 is the 0.975 quantile of Student's t distribution with df degrees of
+// freedom. It is tabulated up to 30 and at 40, 60 and 120, and in between
+// interpolated linearly in 1/df, which is within 0.001 of the exact value.
+func t975(df int) float64 {
+	table := [...]float64{
+		12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+		2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+		2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+	}
+	if df <= 0 {
+		return math.Inf(1)
+	}
+	if df <= len(table) {
+		return table[df-1]
+	}
+	// df and the quantile; an infinite df has the normal's 1.960.
+	tail := []struct {
+		df int
+		t  float64
+	}{{len(table), table[len(table)-1]}, {40, 2.021}, {60, 2.000}, {120, 1.980}, {math.MaxInt, 1.960}}
+	for i := 1; i < len(tail); i++ {
+		lo, hi := tail[i-1], tail[i]
+		if df <= hi.df {
+			x, x0, x1 := 1/float64(df), 1/float64(lo.df), 1/float64(hi.df)
+			return lo.t + (x0-x)/(x0-x1)*(hi.t-lo.t)
+		}
+	}
+	return 1.960
+}
+
+// Percentile returns the p-th percentile (0 <= p <= 100) of sorted using
+// the nearest-rank method, or 0 if sorted is empty.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMeanStdDev(t *testing.T) {
+	xs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	if got := Mean(xs); got != 5 {
+		t.Errorf("Mean = %v, want 5", got)
+	}
+	if got, want := StdDev(xs), math.Sqrt(32.0/7); math.Abs(got-want) > 1e-12 {
+		t.Errorf("StdDev = %v, want %v", got, want)
+	}
+}
+
+func TestCI95(t *testing.T) {
+	lo, hi := CI95([]float64{10, 12})
+	// mean 11, sd sqrt(2), t(1) = 12.706
+	h := 12.706 * math.Sqrt2 / math.Sqrt2
+	if math.Abs(lo-(11-h)) > 1e-9 || math.Abs(hi-(11+h)) > 1e-9 {
+		t.Errorf("CI95 = [%v, %v], want [%v, %v]", lo, hi, 11-h, 11+h)
+	}
+	if lo, hi := CI95([]float64{3}); lo != 3 || hi != 3 {
+		t.Errorf("CI95 of one sample = [%v, %v], want [3, 3]", lo, hi)
+	}
+}
+
+func TestT975(t *testing.T) {
+	// Exact values to three decimals.
+	tests := []struct {
+		df   int
+		want float64
+	}{
+		{1, 12.706}, {30, 2.042}, {31, 2.040}, {35, 2.030}, {40, 2.021},
+		{45, 2.014}, {50, 2.009}, {60, 2.000}, {80, 1.990}, {120, 1.980}, {1000, 1.962},
+	}
+	for _, tt := range tests {
+		if got := t975(tt.df); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("t975(%d) = %.4f, want %.3f", tt.df, got, tt.want)
+		}
+	}
+	for df := 30; df < 200; df++ {
+		if t975(df+1) > t975(df) {
+			t.Errorf("t975(%d) = %v > t975(%d) = %v", df+1, t975(df+1), df, t975(df))
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	xs := []float64{15, 20, 35, 40, 50}
+	tests := []struct{ p, want float64 }{
+		{0, 15}, {5, 15}, {30, 20}, {40, 20}, {50, 35}, {100, 50},
+	}
+	for _, tt := range tests {
+		if got := Percentile(xs, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile(nil) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,232 @@
+package teach
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/escape"
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+)
+
+// readmeProgram is the program of the Readme.
+const readmeProgram = `package main
+
+type BigStruct struct {
+	Buf [1 << 18]byte
+}
+
+var obj BigStruct
+
+func main() {
+	PassByValue(obj)
+	PassByPointer(&obj)
+}
+
+func PassByValue(obj BigStruct) {}
+
+func PassByPointer(obj *BigStruct) {}
+`
+
+const escapeProgram = `package main
+
+type BigStruct struct {
+	Buf [1 << 18]byte
+}
+
+var keep *BigStruct
+
+//go:noinline
+func store(p *BigStruct) { keep = p }
+
+func main() {
+	var v BigStruct
+	store(&v)
+}
+`
+
+var inf = math.Inf(1)
+
+// Lessons are the experiments in the order they are taught.
+var Lessons = []*Lesson{
+	{
+		Title: "Does the copy survive optimization?",
+		Intro: "The Readme's program passes a 256KB BigStruct to PassByValue, " +
+			"an empty function. We build it the normal way, with optimizations and " +
+			"inlining on, and read the stack frame main.main reserves from the compiler's assembly.",
+		Question: "How large is main.main's stack frame?",
+		Choices: []Choice{
+			{"256KB or more: room for the copy of obj", 1 << 18, inf},
+			{"between 1KB and 256KB", 1 << 10, 1 << 18},
+			{"under 1KB", 0, 1 << 10},
+		},
+		Unit:    "bytes",
+		Measure: frameOf("inline", readmeProgram, "main.main"),
+		Explain: "PassByValue is small enough to be inlined, and once inlined the copy " +
+			"into the unused parameter is removed. Not entirely for free: the inlined " +
+			"parameter is too large for the stack, so the runtime.newobject call still " +
+			"allocates 256KB on the heap for it. The copy comes back as soon as the " +
+			"callee is not inlined.",
+	},
+	{
+		Title: "And without inlining?",
+		Intro: "The same program, built with -gcflags=-l so that no function is inlined, " +
+			"as happens to any callee too large or too complex to inline.",
+		Question: "How large is main.main's stack frame now?",
+		Choices: []Choice{
+			{"256KB or more: room for the copy of obj", 1 << 18, inf},
+			{"between 1KB and 256KB", 1 << 10, 1 << 18},
+			{"under 1KB", 0, 1 << 10},
+		},
+		Unit:    "bytes",
+		Measure: frameOf("noinline", readmeProgram, "main.main", "-l"),
+		Explain: "Arguments that do not fit in registers are passed on the caller's " +
+			"stack, so main.main reserves 256KB and copies obj into it (the REP MOVSQ) " +
+			"on every call. The pointer needs one register.",
+	},
+	{
+		Title: "What does the copy cost?",
+		Intro: "We time a call that passes a 256KB array by value and one that passes " +
+			"a pointer to it, both to functions that are never inlined.",
+		Question: "How many times slower is the call by value?",
+		Choices: []Choice{
+			{"less than 2x", 0, 2},
+			{"2x to 20x", 2, 20},
+			{"20x to 200x", 20, 200},
+			{"more than 200x", 200, inf},
+		},
+		Unit:    "x",
+		Measure: ratio("sweep/value/262144", "sweep/pointer/262144"),
+		Explain: "Copying 256KB streams it through the caches on every call, while the " +
+			"pointer costs one register move however large the value is.",
+	},
+	{
+		Title: "Are small values cheaper by pointer?",
+		Intro: "The same comparison for a 16 byte array, the size of a string header " +
+			"or two ints.",
+		Question: "How does the call by value compare with the call by pointer?",
+		Choices: []Choice{
+			{"clearly faster", 0, 0.8},
+			{"about the same", 0.8, 1.25},
+			{"clearly slower", 1.25, inf},
+		},
+		Unit:    "x the pointer's time",
+		Measure: ratio("sweep/value/16", "sweep/pointer/16"),
+		Explain: "Small values travel in registers just like pointers do, so passing them " +
+			"by value costs nothing extra, and it spares the callee an indirection and " +
+			"the garbage collector a pointer to follow.",
+	},
+	{
+		Title: "Where does a pointed-to variable live?",
+		Intro: "main declares a BigStruct v and passes &v to store, which keeps the " +
+			"pointer in a global. We ask the compiler's escape analysis (-gcflags=-m) " +
+			"where it put v.",
+		Question: "Where does v live?",
+		Choices: []Choice{
+			{"on main's stack", 0, 1},
+			{"on the heap", 1, 2},
+		},
+		Unit:    "(1 means moved to the heap)",
+		Measure: movedToHeap("escape", escapeProgram, "v"),
+		Explain: "A pointer that outlives the call forces its target onto the heap: " +
+			"passing &v avoided the copy but costs an allocation and work for the " +
+			"garbage collector. Pointers are cheap to pass, not always cheap to have.",
+	},
+	{
+		Title: "Starting a goroutine with a large argument",
+		Intro: "We start a goroutine with a 32KB array as its by-value argument and " +
+			"wait for it, then do the same passing a pointer to the array.",
+		Question: "How many times slower is starting it with the array by value?",
+		Choices: []Choice{
+			{"less than 2x", 0, 2},
+			{"2x to 5x", 2, 5},
+			{"5x or more", 5, inf},
+		},
+		Unit:    "x",
+		Measure: ratio("goroutine/value/32768", "goroutine/pointer/32768"),
+		Explain: "The go statement copies the argument into a closure on the heap, and " +
+			"the new goroutine's stack, which starts at a few KB, doubles and copies " +
+			"itself until the argument fits. Passing a pointer, or capturing the " +
+			"variable, starts the goroutine on its initial stack.",
+	},
+}
+
+// frameOf builds src with -gcflags=-S and reports the frame size of fn.
+func frameOf(name, src, fn string, gcflags ...string) func(*Env) (float64, string, error) {
+	return func(env *Env) (float64, string, error) {
+		dir, err := env.Program(name, src)
+		if err != nil {
+			return 0, "", err
+		}
+		funcs, err := asm.Compile(dir, gcflags...)
+		if err != nil {
+			return 0, "", err
+		}
+		for _, f := range funcs {
+			if f.Name != fn {
+				continue
+			}
+			var b strings.Builder
+			fmt.Fprintf(&b, "go build -gcflags=%q\n", strings.Join(append([]string{"-S"}, gcflags...), " "))
+			for _, in := range f.Instrs {
+				switch in.Op {
+				case "TEXT", "REP", "MOVSQ", "DUFFCOPY", "CALL":
+					fmt.Fprintf(&b, "main.go:%d\t%s\t%s\n", in.Line, in.Op, in.Args)
+				}
+			}
+			return float64(f.Frame), b.String(), nil
+		}
+		return 0, "", fmt.Errorf("%s not found in the assembly", fn)
+	}
+}
+
+// ratio runs two scenarios and reports the first one's time over the
+// second's.
+func ratio(a, b string) func(*Env) (float64, string, error) {
+	return func(env *Env) (float64, string, error) {
+		ra, err := runScenario(a)
+		if err != nil {
+			return 0, "", err
+		}
+		rb, err := runScenario(b)
+		if err != nil {
+			return 0, "", err
+		}
+		evidence := fmt.Sprintf("%-24s %10.2f ns/op\n%-24s %10.2f ns/op", ra.Name, ra.NsPerOp, rb.Name, rb.NsPerOp)
+		return ra.NsPerOp / rb.NsPerOp, evidence, nil
+	}
+}
+
+func runScenario(name string) (scenario.Result, error) {
+	s := scenario.Lookup(name)
+	if s == nil {
+		return scenario.Result{}, fmt.Errorf("no scenario %s", name)
+	}
+	return scenario.Run(s), nil
+}
+
+// movedToHeap runs escape analysis on src and reports 1 if variable v of
+// main is moved to the heap.
+func movedToHeap(name, src, v string) func(*Env) (float64, string, error) {
+	return func(env *Env) (float64, string, error) {
+		dir, err := env.Program(name, src)
+		if err != nil {
+			return 0, "", err
+		}
+		diags, err := escape.Run(dir)
+		if err != nil {
+			return 0, "", err
+		}
+		var b strings.Builder
+		b.WriteString("go build -gcflags=-m\n")
+		moved := 0.0
+		for _, d := range diags {
+			fmt.Fprintf(&b, "main.go:%d:%d: %s\n", d.Line, d.Col, d.Message)
+			if d.Kind == escape.MovedToHeap && d.Subject == v {
+				moved = 1
+			}
+		}
+		return moved, b.String(), nil
+	}
+}
@@ -0,0 +1,149 @@
+// Package teach runs guided experiments: the learner predicts an outcome,
+// the experiment is run live, and the measurement decides which prediction
+// was right.
+package teach
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A Lesson is one experiment with a multiple choice prediction.
+type Lesson struct {
+	Title    string
+	Intro    string // what the experiment does
+	Question string
+	Choices  []Choice
+	Unit     string // of the measured value, e.g. "bytes" or "x"
+	// Measure runs the experiment and returns the value that picks the
+	// right choice, with the output that shows it.
+	Measure func(env *Env) (value float64, evidence string, err error)
+	Explain string // shown after the reveal
+}
+
+// Choice is a possible outcome: the measured value falls in [Lo, Hi).
+type Choice struct {
+	Text   string
+	Lo, Hi float64
+}
+
+// Answer returns the index of the choice that contains v, or -1.
+func (l *Lesson) Answer(v float64) int {
+	for i, c := range l.Choices {
+		if v >= c.Lo && v < c.Hi {
+			return i
+		}
+	}
+	return -1
+}
+
+// Env is what the experiments run with.
+type Env struct {
+	Dir string // scratch directory for the programs the lessons build
+}
+
+// Program writes a main package with the given source into a module of its
+// own under env.Dir and returns its directory.
+func (env *Env) Program(name, src string) (string, error) {
+	dir := filepath.Join(env.Dir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+name+"\n\ngo 1.22\n"), 0o644); err != nil {
+		return "", err
+	}
+	return dir, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644)
+}
+
+// Score is the outcome of a session.
+type Score struct {
+	Correct, Answered, Skipped int
+}
+
+// Run walks through the lessons, reading predictions from in and writing
+// to out. A prediction is a choice letter; "s" skips the lesson and "q"
+// ends the session.
+func Run(lessons []*Lesson, env *Env, in io.Reader, out io.Writer) (Score, error) {
+	var score Score
+	sc := bufio.NewScanner(in)
+	for n, l := range lessons {
+		fmt.Fprintf(out, "\n== %d/%d: %s ==\n\n%s\n\n%s\n", n+1, len(lessons), l.Title, wrap(l.Intro), wrap(l.Question))
+		for i, c := range l.Choices {
+			fmt.Fprintf(out, "  %c) %s\n", 'a'+i, c.Text)
+		}
+		guess := -1
+	prompt:
+		for {
+			fmt.Fprintf(out, "Your prediction [a-%c, s to skip, q to quit]: ", 'a'+len(l.Choices)-1)
+			if !sc.Scan() {
+				return score, sc.Err()
+			}
+			switch answer := strings.ToLower(strings.TrimSpace(sc.Text())); {
+			case answer == "q":
+				return score, nil
+			case answer == "s":
+				break prompt
+			case len(answer) == 1 && answer[0] >= 'a' && int(answer[0]-'a') < len(l.Choices):
+				guess = int(answer[0] - 'a')
+				break prompt
+			}
+		}
+		if guess < 0 {
+			score.Skipped++
+			continue
+		}
+
+		fmt.Fprintln(out, "\nRunning the experiment...")
+		value, evidence, err := l.Measure(env)
+		if err != nil {
+			return score, fmt.Errorf("%s: %v", l.Title, err)
+		}
+		fmt.Fprintf(out, "\n%s\n", indent(evidence))
+		answer := l.Answer(value)
+		switch {
+		case answer < 0:
+			score.Skipped++
+			fmt.Fprintf(out, "Measured %.4g %s, which none of the choices covers; not graded.\n", value, l.Unit)
+		case answer == guess:
+			score.Answered++
+			score.Correct++
+			fmt.Fprintf(out, "Measured %.4g %s: %c) is right. Correct!\n", value, l.Unit, 'a'+answer)
+		default:
+			score.Answered++
+			fmt.Fprintf(out, "Measured %.4g %s: %c) is right, not %c).\n", value, l.Unit, 'a'+answer, 'a'+guess)
+		}
+		fmt.Fprintf(out, "\n%s\n", wrap(l.Explain))
+	}
+	fmt.Fprintf(out, "\nScore: %d of %d predictions right", score.Correct, score.Answered)
+	if score.Skipped > 0 {
+		fmt.Fprintf(out, ", %d skipped", score.Skipped)
+	}
+	fmt.Fprintln(out, ".")
+	return score, nil
+}
+
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n    ")
+}
+
+// wrap breaks text into lines of at most 76 columns.
+func wrap(text string) string {
+	var b strings.Builder
+	col := 0
+	for _, word := range strings.Fields(text) {
+		if col > 0 && col+1+len(word) > 76 {
+			b.WriteByte('\n')
+			col = 0
+		} else if col > 0 {
+			b.WriteByte(' ')
+			col++
+		}
+		b.WriteString(word)
+		col += len(word)
+	}
+	return b.String()
+}
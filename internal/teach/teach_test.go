@@ -0,0 +1,78 @@
+package teach
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func fixed(v float64) func(*Env) (float64, string, error) {
+	return func(*Env) (float64, string, error) { return v, "measured", nil }
+}
+
+func testLessons() []*Lesson {
+	choices := []Choice{{"small", 0, 10}, {"large", 10, 100}}
+	return []*Lesson{
+		{Title: "one", Choices: choices, Measure: fixed(5)},
+		{Title: "two", Choices: choices, Measure: fixed(50)},
+		{Title: "three", Choices: choices, Measure: fixed(500)},
+		{Title: "four", Choices: choices, Measure: fixed(5)},
+	}
+}
+
+func TestRun(t *testing.T) {
+	var out strings.Builder
+	// Lesson one is answered right after an invalid answer, two wrongly,
+	// three measures outside every choice and four is skipped.
+	score, err := Run(testLessons(), &Env{Dir: t.TempDir()}, strings.NewReader("x\nA\na\nb\ns\n"), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score != (Score{Correct: 1, Answered: 2, Skipped: 2}) {
+		t.Errorf("score = %+v", score)
+	}
+	for _, want := range []string{"a) is right. Correct!", "b) is right, not a).", "not graded", "Score: 1 of 2 predictions right, 2 skipped."} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunQuit(t *testing.T) {
+	lessons := testLessons()
+	lessons[1].Measure = func(*Env) (float64, string, error) { return 0, "", errors.New("broken") }
+	score, err := Run(lessons, &Env{}, strings.NewReader("a\nq\n"), &strings.Builder{})
+	if err != nil || score.Correct != 1 {
+		t.Errorf("Run = %+v, %v; want to stop at q", score, err)
+	}
+	if _, err := Run(lessons, &Env{}, strings.NewReader("a\na\n"), &strings.Builder{}); err == nil {
+		t.Error("Run did not report the failing experiment")
+	}
+}
+
+func TestLessons(t *testing.T) {
+	for _, l := range Lessons {
+		if l.Title == "" || l.Question == "" || l.Measure == nil || len(l.Choices) < 2 {
+			t.Errorf("incomplete lesson %q", l.Title)
+		}
+		for i := 1; i < len(l.Choices); i++ {
+			a, b := l.Choices[i-1], l.Choices[i]
+			if a.Hi != b.Lo && a.Lo != b.Hi {
+				t.Errorf("%s: choices %q and %q leave a gap", l.Title, a.Text, b.Text)
+			}
+		}
+	}
+}
+
+func TestFrameOf(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+	frame, evidence, err := frameOf("noinline", readmeProgram, "main.main", "-l")(&Env{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame < 1<<18 || !strings.Contains(evidence, "main.PassByValue") {
+		t.Errorf("frame = %v, evidence:\n%s", frame, evidence)
+	}
+}
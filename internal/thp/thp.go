@@ -0,0 +1,101 @@
+// Package thp reads and changes the transparent huge page settings of the
+// Linux kernel, explaining why when the host does not allow it.
+package thp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// Dir is where the kernel exposes the settings.
+var Dir = "/sys/kernel/mm/transparent_hugepage"
+
+// Setting is one of the kernel's multiple choice settings, e.g. "enabled"
+// read from "always [madvise] never".
+type Setting struct {
+	Current string   `json:"current"`
+	Options []string `json:"options"`
+}
+
+// Settings are the settings the comparisons depend on.
+type Settings struct {
+	Enabled Setting `json:"enabled"`
+	Defrag  Setting `json:"defrag"`
+}
+
+// Read returns the current settings.
+func Read() (Settings, error) {
+	var s Settings
+	var err error
+	if s.Enabled, err = read("enabled"); err != nil {
+		return s, err
+	}
+	if s.Defrag, err = read("defrag"); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func read(name string) (Setting, error) {
+	data, err := os.ReadFile(filepath.Join(Dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Setting{}, fmt.Errorf("transparent huge pages are not available: %s does not exist (not Linux, or a kernel built without them)", Dir)
+		}
+		return Setting{}, fmt.Errorf("reading transparent huge page settings: %v", err)
+	}
+	return Parse(string(data)), nil
+}
+
+// Parse decodes a setting as the kernel prints it, with the current option
+// in brackets.
+func Parse(text string) Setting {
+	var s Setting
+	for _, f := range strings.Fields(text) {
+		if opt, ok := strings.CutPrefix(f, "["); ok {
+			opt = strings.TrimSuffix(opt, "]")
+			s.Current = opt
+			f = opt
+		}
+		s.Options = append(s.Options, f)
+	}
+	return s
+}
+
+// SetEnabled changes the "enabled" setting to mode and returns a function
+// that restores the previous one. The setting is system wide, so callers
+// should restore it as soon as they are done.
+func SetEnabled(mode string) (restore func() error, err error) {
+	cur, err := read("enabled")
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(cur.Options, mode) {
+		return nil, fmt.Errorf("transparent huge pages: this kernel offers %s, not %q", strings.Join(cur.Options, ", "), mode)
+	}
+	if mode == cur.Current {
+		return func() error { return nil }, nil
+	}
+	if err := write("enabled", mode); err != nil {
+		return nil, err
+	}
+	return func() error { return write("enabled", cur.Current) }, nil
+}
+
+func write(name, value string) error {
+	err := os.WriteFile(filepath.Join(Dir, name), []byte(value), 0)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, os.ErrPermission):
+		return fmt.Errorf("transparent huge pages: changing %s needs root: %v", name, err)
+	case errors.Is(err, syscall.EROFS):
+		return fmt.Errorf("transparent huge pages: %s is read-only here, as in most containers: %v", Dir, err)
+	}
+	return fmt.Errorf("transparent huge pages: setting %s to %q: %v", name, value, err)
+}
@@ -0,0 +1,49 @@
+package thp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	got := Parse("always [madvise] never\n")
+	want := Setting{Current: "madvise", Options: []string{"always", "madvise", "never"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	dir := t.TempDir()
+	defer func(old string) { Dir = old }(Dir)
+	Dir = dir
+	if _, err := Read(); err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Errorf("Read without settings: err = %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "enabled"), []byte("always [madvise] never\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "defrag"), []byte("always defer [madvise] never\n"), 0o644)
+	s, err := Read()
+	if err != nil || s.Enabled.Current != "madvise" || s.Defrag.Current != "madvise" {
+		t.Fatalf("Read = %+v, %v", s, err)
+	}
+	if _, err := SetEnabled("sometimes"); err == nil {
+		t.Error("SetEnabled accepted a mode the kernel does not offer")
+	}
+	restore, err := SetEnabled("never")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "enabled")); string(data) != "never" {
+		t.Errorf("enabled = %q after SetEnabled(never)", data)
+	}
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "enabled")); string(data) != "madvise" {
+		t.Errorf("enabled = %q after restore", data)
+	}
+}
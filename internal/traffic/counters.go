@@ -0,0 +1,39 @@
+package traffic
+
+import "time"
+
+// LineSize is the cache line size assumed when turning cache misses into
+// bytes moved to and from memory.
+const LineSize = 64
+
+// Sample is the hardware event counts of an operation, per operation.
+type Sample struct {
+	N      int                `json:"n"`
+	Events map[string]float64 `json:"events"`
+	// Missing lists the events the machine does not count, with the reason.
+	Missing map[string]string `json:"missing,omitempty"`
+}
+
+// MemoryBytes estimates the bytes moved between the last level cache and
+// memory per operation, or returns false if last level misses are not
+// counted.
+func (s Sample) MemoryBytes() (float64, bool) {
+	misses, ok := s.Events["llc-misses"]
+	return misses * LineSize, ok
+}
+
+// run calls op in doubling batches until d has passed between start and
+// stop, and returns the number of calls.
+func run(op func(), d time.Duration, start, stop func()) int {
+	n := 0
+	begin := time.Now()
+	start()
+	for batch := 1; time.Since(begin) < d; batch *= 2 {
+		for range batch {
+			op()
+		}
+		n += batch
+	}
+	stop()
+	return n
+}
@@ -0,0 +1,165 @@
+package traffic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// perfAttr is struct perf_event_attr up to config2 (PERF_ATTR_SIZE_VER1).
+type perfAttr struct {
+	Type         uint32
+	Size         uint32
+	Config       uint64
+	SamplePeriod uint64
+	SampleType   uint64
+	ReadFormat   uint64
+	Flags        uint64
+	WakeupEvents uint32
+	BpType       uint32
+	Config1      uint64
+	Config2      uint64
+}
+
+const (
+	perfTypeHardware = 0
+	perfTypeHWCache  = 3
+
+	perfFlagDisabled      = 1 << 0
+	perfFlagExcludeKernel = 1 << 5
+	perfFlagExcludeHV     = 1 << 6
+
+	perfIOCEnable  = 0x2400
+	perfIOCDisable = 0x2401
+	perfIOCReset   = 0x2403
+
+	perfFlagFDCloexec = 1 << 3
+)
+
+// events are the generic perf events counted. Cache events are encoded as
+// cache | op<<8 | result<<16.
+var events = []struct {
+	name   string
+	typ    uint32
+	config uint64
+}{
+	{"l1d-loads", perfTypeHWCache, 0 | 0<<8 | 0<<16},
+	{"l1d-stores", perfTypeHWCache, 0 | 1<<8 | 0<<16},
+	{"l1d-load-misses", perfTypeHWCache, 0 | 0<<8 | 1<<16},
+	{"llc-misses", perfTypeHardware, 3},
+}
+
+// Count runs op for about d with the hardware cache counters enabled on
+// every thread of the process and returns the counts per operation. Threads
+// started while op runs are not counted. It fails if none of the events can
+// be counted, e.g. in virtual machines without a PMU or when
+// /proc/sys/kernel/perf_event_paranoid forbids it.
+func Count(op func(), d time.Duration) (Sample, error) {
+	tids, err := threads()
+	if err != nil {
+		return Sample{}, err
+	}
+	s := Sample{Events: make(map[string]float64), Missing: make(map[string]string)}
+	fds := make(map[string][]int)
+	defer func() {
+		for _, list := range fds {
+			for _, fd := range list {
+				syscall.Close(fd)
+			}
+		}
+	}()
+	for _, ev := range events {
+		for _, tid := range tids {
+			fd, err := open(ev.typ, ev.config, tid)
+			if err != nil {
+				if errors.Is(err, syscall.ESRCH) {
+					continue // the thread exited
+				}
+				s.Missing[ev.name] = reason(err)
+				break
+			}
+			fds[ev.name] = append(fds[ev.name], fd)
+		}
+		if _, missing := s.Missing[ev.name]; missing {
+			for _, fd := range fds[ev.name] {
+				syscall.Close(fd)
+			}
+			delete(fds, ev.name)
+		}
+	}
+	if len(fds) == 0 {
+		return s, fmt.Errorf("no hardware counters: %s", s.Missing[events[0].name])
+	}
+
+	ioctl := func(req uintptr) {
+		for _, list := range fds {
+			for _, fd := range list {
+				syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, 0)
+			}
+		}
+	}
+	s.N = run(op, d, func() {
+		ioctl(perfIOCReset)
+		ioctl(perfIOCEnable)
+	}, func() {
+		ioctl(perfIOCDisable)
+	})
+	for name, list := range fds {
+		var total uint64
+		for _, fd := range list {
+			var buf [8]byte
+			if _, err := syscall.Read(fd, buf[:]); err != nil {
+				return s, fmt.Errorf("reading %s: %v", name, err)
+			}
+			total += binary.NativeEndian.Uint64(buf[:])
+		}
+		s.Events[name] = float64(total) / float64(s.N)
+	}
+	return s, nil
+}
+
+func open(typ uint32, config uint64, tid int) (int, error) {
+	attr := perfAttr{
+		Type:   typ,
+		Config: config,
+		Flags:  perfFlagDisabled | perfFlagExcludeKernel | perfFlagExcludeHV,
+	}
+	attr.Size = uint32(unsafe.Sizeof(attr))
+	fd, _, errno := syscall.Syscall6(syscall.SYS_PERF_EVENT_OPEN,
+		uintptr(unsafe.Pointer(&attr)), uintptr(tid), ^uintptr(0), ^uintptr(0), perfFlagFDCloexec, 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// reason explains why perf_event_open failed.
+func reason(err error) string {
+	switch {
+	case errors.Is(err, syscall.ENOENT), errors.Is(err, syscall.EOPNOTSUPP), errors.Is(err, syscall.ENODEV):
+		return "the event is not supported by this CPU or virtual machine"
+	case errors.Is(err, syscall.EACCES), errors.Is(err, syscall.EPERM):
+		return "not permitted; see /proc/sys/kernel/perf_event_paranoid"
+	}
+	return err.Error()
+}
+
+// threads lists the thread ids of the process.
+func threads() ([]int, error) {
+	entries, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return nil, err
+	}
+	var tids []int
+	for _, e := range entries {
+		if tid, err := strconv.Atoi(e.Name()); err == nil {
+			tids = append(tids, tid)
+		}
+	}
+	return tids, nil
+}
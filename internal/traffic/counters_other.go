@@ -0,0 +1,13 @@
+//go:build !linux
+
+package traffic
+
+import (
+	"errors"
+	"time"
+)
+
+// Count reports that hardware counters are only read on Linux.
+func Count(op func(), d time.Duration) (Sample, error) {
+	return Sample{}, errors.New("hardware counters are only read on Linux")
+}
@@ -0,0 +1,329 @@
+// Package traffic estimates the memory traffic of an operation: statically,
+// from the copies in its machine code, and at run time from hardware cache
+// counters where the machine exposes them.
+package traffic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+)
+
+// Copies is the traffic of the copies found in the code of a function and
+// the functions it calls directly.
+type Copies struct {
+	Read  int64 `json:"read_bytes"`  // bytes loaded by copies, per call
+	Write int64 `json:"write_bytes"` // bytes stored by copies, per call
+	// Unknown counts the copies whose size is not a constant, such as
+	// calls to runtime.typedmemmove or runtime.growslice.
+	Unknown int `json:"unknown"`
+}
+
+func (c *Copies) add(o Copies, times int64) {
+	c.Read += o.Read * times
+	c.Write += o.Write * times
+	c.Unknown += o.Unknown * int(times)
+}
+
+// Static adds up the copies made by the function at address entry and the
+// functions of funcs it calls directly, once per call site. Indirect calls,
+// calls to functions outside funcs and loops other than the copy loops the
+// compiler emits are not followed, so the result is a lower bound. Only
+// amd64 code is understood.
+func Static(funcs []*asm.Func, entry uint64, goarch string) (Copies, error) {
+	if goarch != "amd64" {
+		return Copies{}, fmt.Errorf("copy sizes are only derived from amd64 code, not %s", goarch)
+	}
+	byName := make(map[string]*asm.Func)
+	var root *asm.Func
+	for _, f := range funcs {
+		byName[f.Name] = f
+		if f.Addr == entry {
+			root = f
+		}
+	}
+	if root == nil {
+		return Copies{}, fmt.Errorf("no function at %#x", entry)
+	}
+	var walk func(f *asm.Func, active map[string]bool) Copies
+	walk = func(f *asm.Func, active map[string]bool) Copies {
+		active[f.Name] = true
+		defer delete(active, f.Name)
+		c, calls := scan(f)
+		for _, call := range calls {
+			if g := byName[call]; g != nil && !active[call] {
+				c.add(walk(g, active), 1)
+			}
+		}
+		return c
+	}
+	return walk(root, make(map[string]bool)), nil
+}
+
+// Copy routines of the runtime. The size of a memmove is its third
+// argument, in CX under the register ABI; the others take a type.
+var copyFuncs = map[string]bool{
+	"runtime.memmove":             true,
+	"runtime.typedmemmove":        true,
+	"runtime.typedslicecopy":      true,
+	"runtime.wbMove":              true,
+	"runtime.bulkBarrierPreWrite": true,
+}
+
+// Runtime routines that copy a value into memory they allocate: to grow a
+// slice, to convert between strings and byte slices and to box a value in
+// an interface. The conversions take the length in CX, so it is known when
+// it is a constant; the size of the convT* routines for fixed size values
+// is in their name, and the others take a type.
+var (
+	allocCopyFuncs = map[string]bool{
+		"runtime.growslice":         true,
+		"runtime.slicecopy":         true,
+		"runtime.slicebytetostring": true,
+		"runtime.stringtoslicebyte": true,
+		"runtime.convT":             true,
+		"runtime.convTnoptr":        true,
+	}
+	lengthInCX = map[string]bool{
+		"runtime.slicebytetostring": true,
+		"runtime.stringtoslicebyte": true,
+	}
+	convSizes = map[string]int64{
+		"runtime.convT16":     2,
+		"runtime.convT32":     4,
+		"runtime.convT64":     8,
+		"runtime.convTstring": 16,
+		"runtime.convTslice":  24,
+	}
+)
+
+// duffcopy is 64 blocks of 14 bytes of code, each copying 16 bytes; a call
+// enters it at the block that leaves the right number to run.
+const (
+	duffcopyBlocks    = 64
+	duffcopyBlockCode = 14
+	duffcopyBlockData = 16
+)
+
+// Copy is a copy, or with Zero the clearing of memory, made by one
+// instruction of a function.
+type Copy struct {
+	Instr int    // index in the function's instructions
+	Via   string // the instruction or routine, e.g. "REP MOVSQ" or "runtime.memmove"
+	Read  int64  // bytes loaded per execution
+	Write int64  // bytes stored per execution
+	Zero  bool
+	// Unknown is set when the size is not a constant, as for
+	// runtime.typedmemmove or runtime.growslice, and Read and Write are 0.
+	Unknown bool
+	Times   int64 // executions per call, more than 1 inside a counted loop
+}
+
+// Find returns the copies f makes itself and the direct calls to other
+// functions. Only amd64 code is understood.
+func Find(f *asm.Func) ([]Copy, []string) {
+	var (
+		copies []Copy
+		calls  []string
+		mult   = loops(f)
+		regs   = make(map[string]int64) // constants last loaded into registers
+		rep    bool
+	)
+	add := func(i int, via string, read, write int64) {
+		copies = append(copies, Copy{Instr: i, Via: via, Read: read, Write: write, Times: mult[i]})
+	}
+	for i, in := range f.Instrs {
+		switch {
+		case in.Op == "REP":
+			rep = true
+			continue
+		case rep && (strings.HasPrefix(in.Op, "MOVS") || strings.HasPrefix(in.Op, "STOS")):
+			cp := Copy{Instr: i, Via: "REP " + in.Op, Zero: strings.HasPrefix(in.Op, "STOS"), Times: mult[i]}
+			if n, ok := regs["CX"]; ok {
+				cp.Write = n * stringWidth(in.Op)
+				if !cp.Zero {
+					cp.Read = cp.Write
+				}
+			} else {
+				cp.Unknown = true
+			}
+			copies = append(copies, cp)
+		case in.Op == "CALL":
+			target, off := callTarget(in.Args)
+			switch {
+			case target == "runtime.duffcopy":
+				size := (duffcopyBlocks - off/duffcopyBlockCode) * duffcopyBlockData
+				add(i, target, size, size)
+			case target == "runtime.duffzero":
+				copies = append(copies, Copy{Instr: i, Via: target, Zero: true, Unknown: true, Times: mult[i]})
+			case target == "runtime.memmove" && regs["CX"] > 0:
+				add(i, target, regs["CX"], regs["CX"])
+			case lengthInCX[target] && regs["CX"] > 0:
+				add(i, target, regs["CX"], regs["CX"])
+			case convSizes[target] > 0:
+				add(i, target, convSizes[target], convSizes[target])
+			case copyFuncs[target], allocCopyFuncs[target]:
+				copies = append(copies, Copy{Instr: i, Via: target, Unknown: true, Times: mult[i]})
+			case target != "":
+				calls = append(calls, target)
+			}
+			clear(regs)
+		case isVectorMove(in.Op):
+			src, dst, ok := strings.Cut(in.Args, ", ")
+			if !ok {
+				break
+			}
+			switch {
+			case vectorWidth(dst) > 0 && isMemory(src):
+				add(i, in.Op, vectorWidth(dst), 0)
+			case src == "X15" && isMemory(dst):
+				// X15 is always zero under the register ABI.
+				copies = append(copies, Copy{Instr: i, Via: in.Op, Write: 16, Zero: true, Times: mult[i]})
+			case vectorWidth(src) > 0 && isMemory(dst):
+				add(i, in.Op, 0, vectorWidth(src))
+			}
+		case in.Op == "MOVL" || in.Op == "MOVQ":
+			src, dst, ok := strings.Cut(in.Args, ", ")
+			if !ok {
+				break
+			}
+			if n, err := strconv.ParseInt(strings.TrimPrefix(src, "$"), 0, 64); err == nil && strings.HasPrefix(src, "$") {
+				regs[dst] = n
+			} else {
+				delete(regs, dst)
+			}
+		}
+		rep = false
+	}
+	return copies, calls
+}
+
+// scan returns the copies made by f itself and the functions it calls.
+// Clearing memory is not a copy and is left out.
+func scan(f *asm.Func) (Copies, []string) {
+	var c Copies
+	copies, calls := Find(f)
+	for _, cp := range copies {
+		switch {
+		case cp.Zero:
+		case cp.Unknown:
+			c.add(Copies{Unknown: 1}, cp.Times)
+		default:
+			c.add(Copies{Read: cp.Read, Write: cp.Write}, cp.Times)
+		}
+	}
+	return c, calls
+}
+
+// loops returns for every instruction of f the number of times it runs per
+// call, as far as the counted loops of the copy code tell: a backward
+// conditional jump closing a body that decrements a register last set to a
+// constant before the loop.
+func loops(f *asm.Func) []int64 {
+	mult := make([]int64, len(f.Instrs))
+	for i := range mult {
+		mult[i] = 1
+	}
+	for j, in := range f.Instrs {
+		if in.Op != "JNE" {
+			continue
+		}
+		target, err := strconv.ParseUint(strings.TrimPrefix(in.Args, "0x"), 16, 64)
+		if err != nil || target < f.Addr {
+			continue
+		}
+		start := -1
+		for i := range j {
+			if uint64(f.Instrs[i].Offset)+f.Addr == target {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			continue
+		}
+		counter := ""
+		for _, body := range f.Instrs[start:j] {
+			if body.Op == "DECL" || body.Op == "DECQ" {
+				counter = body.Args
+			}
+		}
+		if counter == "" {
+			continue
+		}
+		for i := start - 1; i >= 0; i-- {
+			prev := f.Instrs[i]
+			if src, dst, ok := strings.Cut(prev.Args, ", "); ok && dst == counter && (prev.Op == "MOVL" || prev.Op == "MOVQ") {
+				if n, err := strconv.ParseInt(strings.TrimPrefix(src, "$"), 0, 64); err == nil && strings.HasPrefix(src, "$") {
+					for k := start; k <= j; k++ {
+						mult[k] *= n
+					}
+				}
+				break
+			}
+		}
+	}
+	return mult
+}
+
+// callTarget splits "runtime.duffcopy+0x2a0(SB)" into the symbol and the
+// offset. Indirect calls have no target.
+func callTarget(args string) (string, int64) {
+	sym, ok := strings.CutSuffix(args, "(SB)")
+	if !ok {
+		return "", 0
+	}
+	if i := strings.LastIndex(sym, "+"); i > 0 && !strings.ContainsAny(sym[i:], "[]") {
+		off, err := strconv.ParseInt(sym[i+1:], 0, 64)
+		if err == nil {
+			return sym[:i], off
+		}
+	}
+	return sym, 0
+}
+
+// stringWidth is the width of the elements of MOVSQ, STOSL and the like.
+func stringWidth(op string) int64 {
+	switch op[len(op)-1] {
+	case 'Q':
+		return 8
+	case 'L':
+		return 4
+	case 'W':
+		return 2
+	}
+	return 1
+}
+
+func isVectorMove(op string) bool {
+	switch strings.TrimPrefix(op, "V") {
+	case "MOVUPS", "MOVUPD", "MOVAPS", "MOVAPD", "MOVOU", "MOVO", "MOVDQU", "MOVDQA", "MOVDQU64", "MOVDQA64":
+		return true
+	}
+	return false
+}
+
+// vectorWidth returns the size of a vector register operand, or 0.
+func vectorWidth(arg string) int64 {
+	if len(arg) < 2 {
+		return 0
+	}
+	if _, err := strconv.Atoi(arg[1:]); err != nil {
+		return 0
+	}
+	switch arg[0] {
+	case 'X':
+		return 16
+	case 'Y':
+		return 32
+	case 'Z':
+		return 64
+	}
+	return 0
+}
+
+func isMemory(arg string) bool {
+	return strings.HasSuffix(arg, ")")
+}
@@ -0,0 +1,195 @@
+package traffic
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/valuevspointer/internal/asm"
+	"github.com/rohanchauhan02/valuevspointer/internal/scenario"
+)
+
+const disassembly = `TEXT p.small(SB) p.go
+  p.go:3		0x1000		440f1032		MOVUPS 0(DX), X14
+  p.go:3		0x1004		440f1131		MOVUPS X14, 0(CX)
+  p.go:3		0x1008		e8f3000000		CALL p.loop(SB)
+  p.go:3		0x100d		e8ee000000		CALL p.loop(SB)
+  p.go:4		0x1012		ffd1			CALL CX
+  p.go:5		0x1014		c3			RET
+
+TEXT p.loop(SB) p.go
+  p.go:8		0x1100		bb08000000		MOVL $0x8, BX
+  p.go:8		0x1105		440f1032		MOVUPS 0(DX), X14
+  p.go:8		0x1109		440f1131		MOVUPS X14, 0(CX)
+  p.go:8		0x110d		4883c210		ADDQ $0x10, DX
+  p.go:8		0x1111		4883c110		ADDQ $0x10, CX
+  p.go:8		0x1115		ffcb			DECL BX
+  p.go:8		0x1117		75ec			JNE 0x1105
+  p.go:9		0x1119		b900200000		MOVL $0x2000, CX
+  p.go:9		0x111e		f348a5			REP; MOVSQ DS:0(SI), ES:0(DI)
+  p.go:10		0x1121		b940000000		MOVL $0x40, CX
+  p.go:10		0x1126		e800000000		CALL runtime.memmove(SB)
+  p.go:11		0x112b		e800000000		CALL runtime.typedmemmove(SB)
+  p.go:12		0x1130		e800000000		CALL runtime.duffcopy+0x310(SB)
+  p.go:13		0x1135		e800000000		CALL p.loop(SB)
+  p.go:14		0x113a		b910000000		MOVL $0x10, CX
+  p.go:14		0x113f		f348ab			REP; STOSQ AX, ES:0(DI)
+  p.go:15		0x1142		440f117c2410		MOVUPS X15, 0x10(SP)
+  p.go:16		0x1148		e800000000		CALL runtime.growslice(SB)
+  p.go:17		0x114d		e800000000		CALL runtime.convT64(SB)
+  p.go:18		0x1152		b920000000		MOVL $0x20, CX
+  p.go:18		0x1157		e800000000		CALL runtime.slicebytetostring(SB)
+  p.go:19		0x115c		c3			RET
+`
+
+func TestStatic(t *testing.T) {
+	funcs, err := asm.ParseObjdump(strings.NewReader(disassembly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// loop: 8*16 in the counted loop, 0x2000*8 by REP MOVSQ, 0x40 by
+	// memmove, 0x310 into duffcopy leaves 8 blocks of 16 bytes, 8 boxed
+	// by convT64 and 0x20 converted to a string. The recursive call is not
+	// followed, and zeroing is not a copy.
+	loop := int64(8*16 + 0x2000*8 + 0x40 + 8*16 + 8 + 0x20)
+	c, err := Static(funcs, 0x1100, "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Copies{Read: loop, Write: loop, Unknown: 2}); c != want {
+		t.Errorf("Static(loop) = %+v, want %+v", c, want)
+	}
+	c, err = Static(funcs, 0x1000, "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Copies{Read: 16 + 2*loop, Write: 16 + 2*loop, Unknown: 4}); c != want {
+		t.Errorf("Static(small) = %+v, want %+v", c, want)
+	}
+
+	if _, err := Static(funcs, 0x2000, "amd64"); err == nil {
+		t.Error("Static of an unknown address succeeded")
+	}
+	if _, err := Static(funcs, 0x1000, "arm64"); err == nil {
+		t.Error("Static of arm64 code succeeded")
+	}
+}
+
+func TestFind(t *testing.T) {
+	funcs, err := asm.ParseObjdump(strings.NewReader(disassembly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copies, calls := Find(funcs[1])
+	var got []string
+	for _, c := range copies {
+		got = append(got, fmt.Sprintf("%s %d/%d zero=%v unknown=%v x%d", c.Via, c.Read, c.Write, c.Zero, c.Unknown, c.Times))
+	}
+	want := []string{
+		"MOVUPS 16/0 zero=false unknown=false x8",
+		"MOVUPS 0/16 zero=false unknown=false x8",
+		"REP MOVSQ 65536/65536 zero=false unknown=false x1",
+		"runtime.memmove 64/64 zero=false unknown=false x1",
+		"runtime.typedmemmove 0/0 zero=false unknown=true x1",
+		"runtime.duffcopy 128/128 zero=false unknown=false x1",
+		"REP STOSQ 0/128 zero=true unknown=false x1",
+		"MOVUPS 0/16 zero=true unknown=false x1",
+		"runtime.growslice 0/0 zero=false unknown=true x1",
+		"runtime.convT64 8/8 zero=false unknown=false x1",
+		"runtime.slicebytetostring 32/32 zero=false unknown=false x1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Find(loop) =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(calls) != 1 || calls[0] != "p.loop" {
+		t.Errorf("calls = %q, want [p.loop]", calls)
+	}
+}
+
+func TestCount(t *testing.T) {
+	n := 0
+	s, err := Count(func() { n++ }, 5*time.Millisecond)
+	if err != nil {
+		t.Skipf("hardware counters unavailable on %s: %v", runtime.GOOS, err)
+	}
+	if s.N == 0 || s.N != n {
+		t.Errorf("Count ran op %d times and reported %d", n, s.N)
+	}
+	if len(s.Events) == 0 {
+		t.Errorf("Count returned no events: %+v", s)
+	}
+}
+
+// TestScenarios checks that the copies of growing a slice and of converting
+// bytes to a string are found in the operations of the scenarios, in a
+// build of this test binary that keeps its symbol table.
+func TestScenarios(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and disassembles the test binary")
+	}
+	if runtime.GOARCH != "amd64" {
+		t.Skipf("copy sizes are only derived from amd64 code, not %s", runtime.GOARCH)
+	}
+	bin := filepath.Join(t.TempDir(), "traffic.test")
+	if err := asm.BuildTest(".", bin); err != nil {
+		t.Fatal(err)
+	}
+	pkg := reflect.TypeOf(scenario.Scenario{}).PkgPath()
+	funcs, err := asm.Objdump(bin, "^"+regexp.QuoteMeta(pkg)+`\.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"append/value/64", "conv/string/4096", "conv/bytes/4096"} {
+		s := scenario.Lookup(name)
+		if s == nil {
+			t.Fatalf("no scenario %s", name)
+		}
+		// Symbol names are the same in both builds, addresses are not.
+		// The runtime prints the type arguments of generic functions as
+		// [...], so each of their instances is checked.
+		sym := runtime.FuncForPC(reflect.ValueOf(s.Op()).Pointer()).Name()
+		found := 0
+		for _, f := range funcs {
+			if elideShapes(f.Name) != sym {
+				continue
+			}
+			found++
+			c, err := Static(funcs, f.Addr, "amd64")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Read+c.Write == 0 && c.Unknown == 0 {
+				t.Errorf("%s: no copy found in %s", name, f.Name)
+			}
+		}
+		if found == 0 {
+			t.Errorf("%s: %s not found in the disassembly", name, sym)
+		}
+	}
+}
+
+// elideShapes replaces the type arguments in a symbol with "...", as the
+// runtime prints them.
+func elideShapes(sym string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range sym {
+		switch {
+		case r == '[':
+			if depth == 0 {
+				b.WriteString("[...]")
+			}
+			depth++
+		case r == ']':
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}